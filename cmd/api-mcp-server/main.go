@@ -1,37 +1,87 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/AdamShannag/api-mcp-server/internal/auth"
 	"github.com/AdamShannag/api-mcp-server/internal/mcp"
 	"github.com/AdamShannag/api-mcp-server/internal/monitoring"
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
 	"github.com/AdamShannag/api-mcp-server/internal/util"
+	"github.com/AdamShannag/api-mcp-server/pkg/breaker"
+	"github.com/AdamShannag/api-mcp-server/pkg/bulkhead"
+	"github.com/AdamShannag/api-mcp-server/pkg/cache"
+	"github.com/AdamShannag/api-mcp-server/pkg/netguard"
+	"github.com/AdamShannag/api-mcp-server/pkg/plugin"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
 	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/rpc"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
 	"github.com/AdamShannag/api-mcp-server/pkg/tool"
 	"github.com/lmittmann/tint"
+	"google.golang.org/grpc"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
+	"regexp"
 	"time"
 )
 
-const httpClientTimeout = 30 * time.Second
+const (
+	httpClientTimeout = 30 * time.Second
+	requestTimeout    = 30 * time.Second
+	continuationTTL   = 5 * time.Minute
+	defaultConfigPath = "./config.json"
+)
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(validate(os.Args[2:]))
+		case "secrets":
+			os.Exit(secrets(os.Args[2:]))
+		}
+	}
+
 	var (
 		transport     string
-		toolsFilePath string
+		configPaths   util.StringSlice
 		showVersion   bool
 		enableMetrics bool
 		metricsPort   string
+		maxRespSize   int64
+		uploadDirs    util.StringSlice
+		redactHeaders util.StringSlice
+		redactParams  util.StringSlice
+		redactPattern util.StringSlice
+		allowHosts    util.StringSlice
+		allowNetworks util.StringSlice
+		readOnly      bool
+		dryRun        bool
+		toolLimit     string
+		hostLimit     string
+		sessionLimit  string
+		keyLimit      string
+		failureRatio  float64
+		minRequests   int
+		openTimeout   time.Duration
+		cacheSize     int64
+		cacheDir      string
+		coalesce      bool
+		maxInFlight   int
+		maxPerHost    int
+		queueTimeout  time.Duration
 	)
 	flag.StringVar(&transport, "t", "stdio", "Transport type (stdio or sse)")
 	flag.StringVar(&transport, "transport", "stdio", "Transport type (stdio or sse)")
 
-	flag.StringVar(&toolsFilePath, "c", "./config.json", "Tools config file path")
-	flag.StringVar(&toolsFilePath, "config", "./config.json", "Tools config file path")
+	flag.Var(&configPaths, "c", "Tools config file, directory or glob pattern (repeatable, default ./config.json)")
+	flag.Var(&configPaths, "config", "Tools config file, directory or glob pattern (repeatable, default ./config.json)")
 
 	flag.BoolVar(&showVersion, "v", false, "Show version and exit")
 	flag.BoolVar(&showVersion, "version", false, "Show version and exit")
@@ -39,6 +89,37 @@ func main() {
 	flag.BoolVar(&enableMetrics, "m", false, "Start metrics server")
 	flag.BoolVar(&enableMetrics, "metrics", false, "Start metrics server")
 	flag.StringVar(&metricsPort, "metrics-port", "8080", "Port for metrics endpoint (default: 8080)")
+
+	flag.Int64Var(&maxRespSize, "max-response-size", 0, "Maximum response body size in bytes returned per call (0 means unlimited)")
+	flag.Var(&uploadDirs, "upload-dir", "Directory multipart file parts may be read from (repeatable)")
+
+	flag.BoolVar(&readOnly, "read-only", false, "Only register and execute tools with GET or HEAD requests, or GraphQL queries")
+	flag.BoolVar(&dryRun, "dry-run", false, "Return the resolved requests of tool calls instead of sending them")
+
+	flag.StringVar(&toolLimit, "rate-limit-tool", "", "Rate limit per tool, e.g. 10/s or 100/m")
+	flag.StringVar(&hostLimit, "rate-limit-host", "", "Rate limit per upstream host, e.g. 10/s or 100/m")
+	flag.StringVar(&sessionLimit, "rate-limit-session", "", "Rate limit per session, e.g. 10/s or 100/m")
+	flag.StringVar(&keyLimit, "rate-limit-key", "", "Rate limit per API key, e.g. 10/s or 100/m")
+
+	flag.Float64Var(&failureRatio, "breaker-failure-ratio", 0, "Ratio of failed requests opening the circuit of an upstream host, e.g. 0.5 (0 disables the breaker)")
+	flag.IntVar(&minRequests, "breaker-min-requests", breaker.DefaultMinRequests, "Requests to an upstream host per minute before its failure ratio is considered")
+	flag.DurationVar(&openTimeout, "breaker-open-timeout", breaker.DefaultOpenTimeout, "How long an open circuit fails fast before probing the upstream host")
+
+	flag.Int64Var(&cacheSize, "cache-size", cache.DefaultMaxSize, "Maximum size in bytes of the response cache")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Directory persisting the response cache (default in memory)")
+
+	flag.BoolVar(&coalesce, "coalesce", false, "Share one upstream request between identical concurrent GET and HEAD requests")
+
+	flag.IntVar(&maxInFlight, "max-in-flight", 0, "Maximum upstream requests in flight across all hosts (0 means unlimited)")
+	flag.IntVar(&maxPerHost, "max-in-flight-host", 0, "Maximum upstream requests in flight per host (0 means unlimited)")
+	flag.DurationVar(&queueTimeout, "queue-timeout", bulkhead.DefaultQueueTimeout, "How long requests over the in-flight limits wait for a free slot")
+
+	flag.Var(&allowHosts, "allow-host", "Host, host:port or *.domain upstream requests may target (repeatable, default any)")
+	flag.Var(&allowNetworks, "allow-network", "CIDR of private addresses upstream requests may connect to (repeatable)")
+
+	flag.Var(&redactHeaders, "redact-header", "Header name whose values are masked in logs (repeatable)")
+	flag.Var(&redactParams, "redact-param", "Query param or field name whose values are masked in logs (repeatable)")
+	flag.Var(&redactPattern, "redact-pattern", "Regular expression whose matches are masked in logs (repeatable)")
 	flag.Parse()
 
 	if showVersion {
@@ -46,23 +127,146 @@ func main() {
 		return
 	}
 
-	slog.SetDefault(slog.New(
+	if len(configPaths) == 0 {
+		configPaths = util.StringSlice{defaultConfigPath}
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(redactPattern))
+	for _, p := range redactPattern {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			log.Fatalf("invalid redact pattern %q: %v", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	redactor := redact.New(
+		redact.WithHeaders(redactHeaders...),
+		redact.WithParams(redactParams...),
+		redact.WithPatterns(patterns...),
+	)
+
+	slog.SetDefault(slog.New(redactor.Handler(
 		tint.NewHandler(os.Stderr, &tint.Options{
 			Level:      util.GetLogLevel(os.Getenv("LOG_LEVEL")),
 			TimeFormat: time.DateTime,
 		}),
-	))
+	)))
+
+	networks := make([]netip.Prefix, 0, len(allowNetworks))
+	for _, n := range allowNetworks {
+		prefix, err := netip.ParsePrefix(n)
+		if err != nil {
+			log.Fatalf("invalid allowed network %q: %v", n, err)
+		}
+		networks = append(networks, prefix)
+	}
+	guard := netguard.New(
+		netguard.WithAllowedHosts(allowHosts...),
+		netguard.WithAllowedNetworks(networks...),
+	)
+
+	var limiterOptions []ratelimit.Option
+	for scope, spec := range map[string]string{
+		ratelimit.ScopeTool:    toolLimit,
+		ratelimit.ScopeHost:    hostLimit,
+		ratelimit.ScopeSession: sessionLimit,
+		ratelimit.ScopeKey:     keyLimit,
+	} {
+		if spec == "" {
+			continue
+		}
+		limit, err := ratelimit.Parse(spec)
+		if err != nil {
+			log.Fatalf("invalid --rate-limit-%s: %v", scope, err)
+		}
+		limiterOptions = append(limiterOptions, ratelimit.WithDefault(scope, limit))
+	}
+
+	continuations := request.NewContinuations(continuationTTL, request.DefaultContinuationsMaxSize)
+	httpClient := &http.Client{Timeout: httpClientTimeout, Transport: guard.Transport(nil)}
+	secretStore := secret.NewStore()
+
+	executorOptions := []request.Option{
+		// Tool calls are bounded by their request timeout instead, which may exceed the client timeout.
+		request.WithHttpClient(&http.Client{Transport: httpClient.Transport}),
+		request.WithTimeout(requestTimeout),
+		request.WithMaxResponseSize(maxRespSize),
+		request.WithContinuations(continuations),
+		request.WithUploadDirs(uploadDirs...),
+		request.WithSecrets(secretStore),
+		request.WithRedactor(redactor),
+		request.WithKindExecutor(rpc.KindGRPC, rpc.NewExecutor(
+			rpc.WithSecrets(secretStore),
+			rpc.WithGuard(guard),
+			rpc.WithDialOptions(grpc.WithNoProxy(), grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+				return guard.DialContext(ctx, "tcp", addr)
+			})),
+		)),
+	}
+	managerOptions := []tool.Option{
+		tool.WithContinuations(continuations),
+		tool.WithRedactor(redactor),
+	}
+	cacheBackend := cache.NewMemory(cacheSize)
+	if cacheDir != "" {
+		var err error
+		if cacheBackend, err = cache.NewDisk(cacheDir, cacheSize); err != nil {
+			log.Fatal(err)
+		}
+	}
+	executorOptions = append(executorOptions, request.WithCache(cache.New(cacheBackend,
+		cache.WithObserver(func(host, result string) {
+			monitoring.CacheLookups.WithLabelValues(host, result).Inc()
+		}),
+	)))
+
+	if coalesce {
+		executorOptions = append(executorOptions, request.WithCoalescing(func(host string) {
+			monitoring.CoalescedCalls.WithLabelValues(host).Inc()
+		}))
+	}
+	if maxInFlight > 0 || maxPerHost > 0 {
+		executorOptions = append(executorOptions, request.WithBulkhead(bulkhead.New(
+			bulkhead.WithMaxInFlight(maxInFlight),
+			bulkhead.WithMaxInFlightPerHost(maxPerHost),
+			bulkhead.WithQueueTimeout(queueTimeout),
+			bulkhead.WithQueueObserver(func(host string, depth int) {
+				monitoring.QueueDepth.WithLabelValues(host).Set(float64(depth))
+			}),
+			bulkhead.WithWaitObserver(func(host string, wait time.Duration) {
+				monitoring.QueueWait.WithLabelValues(host).Observe(wait.Seconds())
+			}),
+		)))
+	}
+	if failureRatio > 0 {
+		executorOptions = append(executorOptions, request.WithBreaker(breaker.New(
+			breaker.WithFailureRatio(failureRatio),
+			breaker.WithMinRequests(minRequests),
+			breaker.WithOpenTimeout(openTimeout),
+			breaker.WithObserver(func(host string, _, to breaker.State) {
+				monitoring.CircuitState.WithLabelValues(host).Set(float64(to))
+			}),
+		)))
+	}
+	if readOnly {
+		executorOptions = append(executorOptions, request.WithReadOnly())
+		managerOptions = append(managerOptions, tool.WithReadOnly())
+	}
+	if dryRun {
+		executorOptions = append(executorOptions, request.WithDryRun())
+	}
 
-	manager := tool.NewManager(request.NewExecutor(
-		request.WithHttpClient(&http.Client{Timeout: httpClientTimeout}),
-	))
+	manager := tool.NewManager(request.NewExecutor(executorOptions...), managerOptions...)
 
 	s := mcp.NewServer(transport,
 		mcp.WithHost(os.Getenv("API_MCP_HOST")),
 		mcp.WithPort(os.Getenv("API_MCP_PORT")),
-		mcp.WithToolsFile(toolsFilePath),
+		mcp.WithToolsFiles(configPaths...),
 		mcp.WithAuth(auth.NewAuthenticator("sse", os.Getenv("API_MCP_SSE_API_KEY"))),
 		mcp.WithHttpServer(monitoring.NewHttpServer(enableMetrics, metricsPort)),
+		mcp.WithPluginOptions(plugin.WithHttpClient(httpClient)),
+		mcp.WithSecrets(secretStore, secret.WithHttpClient(httpClient)),
+		mcp.WithRateLimiter(ratelimit.New(limiterOptions...)),
 	)
 
 	err := s.LoadTools(manager)
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
+	"io"
+	"os"
+)
+
+const secretsKeyEnv = "API_MCP_SECRETS_KEY"
+
+// secrets runs the secrets subcommand. "secrets encrypt" encrypts a JSON object of secrets for an
+// encrypted secret provider with the key in API_MCP_SECRETS_KEY. It returns the exit code.
+func secrets(args []string) int {
+	if len(args) == 0 || args[0] != "encrypt" {
+		fmt.Fprintln(os.Stderr, "usage: api-mcp-server secrets encrypt [-in secrets.json] -out secrets.enc")
+		return 2
+	}
+
+	var in, out string
+	flags := flag.NewFlagSet("secrets encrypt", flag.ExitOnError)
+	flags.StringVar(&in, "in", "-", "JSON object of secrets to encrypt, - for stdin")
+	flags.StringVar(&out, "out", "", "Encrypted secrets file to write")
+	_ = flags.Parse(args[1:])
+
+	if out == "" {
+		fmt.Fprintln(os.Stderr, "-out is required")
+		return 2
+	}
+
+	var (
+		plaintext []byte
+		err       error
+	)
+	if in == "-" {
+		plaintext, err = io.ReadAll(os.Stdin)
+	} else {
+		plaintext, err = os.ReadFile(in)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read secrets:", err)
+		return 1
+	}
+
+	data, err := secret.Encrypt(os.Getenv(secretsKeyEnv), plaintext)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to encrypt secrets:", err)
+		return 1
+	}
+	if err = os.WriteFile(out, data, 0600); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write secrets file:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/internal/mcp"
+	"github.com/AdamShannag/api-mcp-server/internal/util"
+	"os"
+)
+
+// validate runs the validate subcommand, which checks the config files and reports every problem found.
+// It returns the exit code.
+func validate(args []string) int {
+	var (
+		configPaths util.StringSlice
+		printSchema bool
+	)
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Var(&configPaths, "c", "Tools config file, directory or glob pattern (repeatable, default ./config.json)")
+	flags.Var(&configPaths, "config", "Tools config file, directory or glob pattern (repeatable, default ./config.json)")
+	flags.BoolVar(&printSchema, "schema", false, "Print the JSON Schema of config files and exit")
+	_ = flags.Parse(args)
+
+	if printSchema {
+		_, _ = os.Stdout.Write(config.Schema)
+		return 0
+	}
+
+	if len(configPaths) == 0 {
+		configPaths = util.StringSlice{defaultConfigPath}
+	}
+
+	err := mcp.NewServer("stdio", mcp.WithToolsFiles(configPaths...)).ValidateTools()
+	if err == nil {
+		fmt.Println("config is valid")
+		return 0
+	}
+
+	var problems config.Problems
+	if !errors.As(err, &problems) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	fmt.Fprintf(os.Stderr, "%d problem(s) found\n", len(problems))
+	return 1
+}
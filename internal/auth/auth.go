@@ -31,6 +31,12 @@ func (a *Authenticator) FromRequest(ctx context.Context, r *http.Request) contex
 	return context.WithValue(ctx, authContextKey, r.Header.Get("Authorization"))
 }
 
+// KeyFromContext returns the API key a request was made with, or an empty string.
+func KeyFromContext(ctx context.Context) string {
+	rawHeader, _ := ctx.Value(authContextKey).(string)
+	return strings.TrimPrefix(rawHeader, "Bearer ")
+}
+
 func (a *Authenticator) Middleware() server.ToolHandlerMiddleware {
 	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
 		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"maps"
+	"net/url"
+	"strings"
+)
+
+// ApplyAPIs applies the API definitions referenced by the tool request and its step requests.
+func ApplyAPIs(apis map[string]types.API, tool *types.Tool) error {
+	if err := applyAPI(apis, &tool.Request); err != nil {
+		return err
+	}
+
+	tool.Steps = append([]types.Step(nil), tool.Steps...)
+	for i := range tool.Steps {
+		if err := applyAPI(apis, &tool.Steps[i].Request); err != nil {
+			return fmt.Errorf("step %q: %w", tool.Steps[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// applyAPI fills in what the request leaves unset from the API it references. Without its own host, the
+// request takes the scheme and host of the API base URL, and its endpoint is appended to the base path.
+// API headers are merged under the request headers.
+func applyAPI(apis map[string]types.API, req *types.Request) error {
+	if req.API == "" {
+		return nil
+	}
+
+	api, ok := apis[req.API]
+	if !ok {
+		return fmt.Errorf("unknown api %q", req.API)
+	}
+
+	if req.Host == "" && api.BaseURL != "" {
+		u, err := url.Parse(api.BaseURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("api %q: invalid baseURL %q", req.API, api.BaseURL)
+		}
+		req.Host = u.Host
+		req.Secure = u.Scheme == "https"
+		req.Endpoint = strings.TrimSuffix(u.Path, "/") + req.Endpoint
+	}
+
+	if len(api.Headers) > 0 {
+		headers := maps.Clone(api.Headers)
+		maps.Copy(headers, req.Headers)
+		req.Headers = headers
+	}
+	if req.Auth == nil {
+		req.Auth = api.Auth
+	}
+	if req.Timeout == "" {
+		req.Timeout = api.Timeout
+	}
+	if req.Retry == nil {
+		req.Retry = api.Retry
+	}
+	return nil
+}
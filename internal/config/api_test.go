@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestApplyAPIs(t *testing.T) {
+	apis := map[string]types.API{
+		"github": {
+			BaseURL: "https://api.github.com/v3/",
+			Headers: map[string]string{"Accept": "application/json", "X-Client": "api"},
+			Auth:    &types.Auth{Type: "bearer", Token: "secret"},
+			Timeout: "10s",
+			Retry:   &types.Retry{Attempts: 2},
+		},
+	}
+
+	steps := []types.Step{{Name: "first", Request: types.Request{API: "github", Endpoint: "/user"}}}
+	tool := types.Tool{
+		Request: types.Request{
+			API:      "github",
+			Endpoint: "/repos",
+			Headers:  map[string]string{"X-Client": "tool"},
+			Timeout:  "1s",
+		},
+		Steps: steps,
+	}
+
+	require.NoError(t, config.ApplyAPIs(apis, &tool))
+
+	assert.Equal(t, "api.github.com", tool.Request.Host)
+	assert.True(t, tool.Request.Secure)
+	assert.Equal(t, "/v3/repos", tool.Request.Endpoint)
+	assert.Equal(t, map[string]string{"Accept": "application/json", "X-Client": "tool"}, tool.Request.Headers)
+	assert.Equal(t, "bearer", tool.Request.Auth.Type)
+	assert.Equal(t, "1s", tool.Request.Timeout)
+	assert.Equal(t, 2, tool.Request.Retry.Attempts)
+
+	assert.Equal(t, "/v3/user", tool.Steps[0].Request.Endpoint)
+	assert.Equal(t, "10s", tool.Steps[0].Request.Timeout)
+	assert.Equal(t, "/user", steps[0].Request.Endpoint, "shared steps are not modified")
+}
+
+func TestApplyAPIs_KeepsRequestHost(t *testing.T) {
+	apis := map[string]types.API{"local": {BaseURL: "https://api.example.com/v1"}}
+	tool := types.Tool{Request: types.Request{API: "local", Host: "localhost:8080", Endpoint: "/items"}}
+
+	require.NoError(t, config.ApplyAPIs(apis, &tool))
+	assert.Equal(t, "localhost:8080", tool.Request.Host)
+	assert.Equal(t, "/items", tool.Request.Endpoint)
+}
+
+func TestApplyAPIs_Errors(t *testing.T) {
+	apis := map[string]types.API{"broken": {BaseURL: "not a url"}}
+
+	err := config.ApplyAPIs(apis, &types.Tool{Request: types.Request{API: "missing"}})
+	assert.ErrorContains(t, err, `unknown api "missing"`)
+
+	err = config.ApplyAPIs(apis, &types.Tool{Request: types.Request{API: "broken"}})
+	assert.ErrorContains(t, err, `api "broken": invalid baseURL`)
+
+	err = config.ApplyAPIs(apis, &types.Tool{Steps: []types.Step{{Name: "s1", Request: types.Request{API: "missing"}}}})
+	assert.ErrorContains(t, err, `step "s1": unknown api "missing"`)
+}
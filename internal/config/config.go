@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Extensions are the file extensions of config files picked up from directories.
+var Extensions = []string{".json", ".jsonc", ".yaml", ".yml"}
+
+// Expand resolves config paths, directories and glob patterns into the list of config files, in order.
+// Directories are walked recursively for files with a supported extension. Files found in directories or
+// by glob patterns are skipped when they are not config files, e.g. introspection results next to the
+// configs. Files found more than once are only returned the first time.
+func Expand(patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		key := path
+		if abs, err := filepath.Abs(path); err == nil {
+			key = abs
+		}
+		if !seen[key] {
+			seen[key] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if isGlob(pattern) {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("invalid config pattern %q: %w", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("config pattern %q matches no files", pattern)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			if !info.IsDir() {
+				ok := true
+				if isGlob(pattern) {
+					if ok, err = isConfigFile(match); err != nil {
+						return nil, err
+					}
+				}
+				if ok {
+					add(match)
+				}
+				continue
+			}
+
+			dirFiles, err := configFiles(match)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range dirFiles {
+				add(f)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// configFiles returns the config files in dir and its subdirectories, in lexical order.
+func configFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !slices.Contains(Extensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		ok, err := isConfigFile(path)
+		if ok {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+	return files, nil
+}
+
+// isConfigFile reports whether a file found in a directory or by a glob pattern is a config file.
+func isConfigFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !IsConfig(path, data) {
+		slog.Debug("skipping file without config keys", slog.String("file", path))
+		return false, nil
+	}
+	return true, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json", "notes.txt", "nested/c.json"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("[]"), 0644))
+	}
+
+	files, err := config.Expand([]string{
+		filepath.Join(dir, "b.json"),
+		dir,
+		filepath.Join(dir, "*.json"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "b.json"),
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "nested", "c.json"),
+	}, files)
+}
+
+func TestExpand_SkipsNonConfigs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"tools.json":          `{"tools": []}`,
+		"schema.json":         `{"data": {"__schema": {}}}`,
+		"nested/values.yaml":  "replicas: 2\n",
+		"nested/apis.yaml":    "apis: {}\n",
+		"nested/invalid.json": "{",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	files, err := config.Expand([]string{dir, filepath.Join(dir, "*.json")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "nested", "apis.yaml"),
+		filepath.Join(dir, "nested", "invalid.json"),
+		filepath.Join(dir, "tools.json"),
+	}, files)
+
+	files, err = config.Expand([]string{filepath.Join(dir, "schema.json")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "schema.json")}, files)
+}
+
+func TestExpand_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := config.Expand([]string{filepath.Join(dir, "missing.json")})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read file")
+
+	_, err = config.Expand([]string{filepath.Join(dir, "*.json")})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "matches no files")
+
+	_, err = config.Expand([]string{filepath.Join(dir, "[")})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid config pattern")
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// yamlLine extracts the line from yaml syntax errors, which carry no column.
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// configKeys are the top-level keys of a config file.
+var configKeys = []string{"prefix", "apis", "plugins", "secrets", "tools"}
+
+// position maps an offset in the decoded JSON to a location in the config file.
+type position struct {
+	offset int64
+	line   int
+	column int
+}
+
+// Decode decodes a config file in the format given by its extension: YAML for .yaml and .yml, JSON with
+// comments and trailing commas for .jsonc, and JSON otherwise. Errors report the file, line and column.
+// Files with none of the config keys are rejected, e.g. an introspection result passed as a config.
+func Decode(file string, data []byte) (types.Config, error) {
+	var (
+		cfg types.Config
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		cfg, err = decodeYAML(file, data)
+	case ".jsonc":
+		cfg, err = decodeJSON(file, "JSONC", stripJSONC(data))
+	default:
+		cfg, err = decodeJSON(file, "JSON", data)
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if !IsConfig(file, data) {
+		return cfg, fmt.Errorf("%s is not a config file: it has none of the keys %s", file, strings.Join(configKeys, ", "))
+	}
+
+	// "http" names the default request kind.
+	for i := range cfg.Tools {
+		cfg.Tools[i].Request.Kind = request.NormalizeKind(cfg.Tools[i].Request.Kind)
+		for j := range cfg.Tools[i].Steps {
+			cfg.Tools[i].Steps[j].Request.Kind = request.NormalizeKind(cfg.Tools[i].Steps[j].Request.Kind)
+		}
+	}
+	return cfg, nil
+}
+
+// IsConfig reports whether a file is an object with at least one of the config keys. Files that are not
+// objects, like bare arrays of tools or invalid files, are reported as configs, leaving them to Decode.
+func IsConfig(file string, data []byte) bool {
+	var err error
+	var keys []string
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		var obj map[string]yaml.Node
+		err = yaml.Unmarshal(data, &obj)
+		keys = slices.Collect(maps.Keys(obj))
+	default:
+		if strings.EqualFold(filepath.Ext(file), ".jsonc") {
+			data = stripJSONC(data)
+		}
+		var obj map[string]json.RawMessage
+		err = json.Unmarshal(data, &obj)
+		keys = slices.Collect(maps.Keys(obj))
+	}
+	if err != nil {
+		return true
+	}
+	return slices.ContainsFunc(keys, func(key string) bool { return slices.Contains(configKeys, key) })
+}
+
+func decodeJSON(file, format string, data []byte) (types.Config, error) {
+	var cfg types.Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		offset, ok := errorOffset(err)
+		if !ok {
+			return cfg, fmt.Errorf("failed to decode %s in %s: %w", format, file, err)
+		}
+		line, column := lineColumn(data, offset)
+		return cfg, fmt.Errorf("failed to decode %s at %s:%d:%d: %w", format, file, line, column, err)
+	}
+	return cfg, nil
+}
+
+func decodeYAML(file string, data []byte) (types.Config, error) {
+	var cfg types.Config
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+			return cfg, fmt.Errorf("failed to decode YAML at %s:%s: %s", file, m[1], m[2])
+		}
+		return cfg, fmt.Errorf("failed to decode YAML in %s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+
+	var buf bytes.Buffer
+	var positions []position
+	if err := yamlToJSON(&buf, doc.Content[0], &positions); err != nil {
+		return cfg, fmt.Errorf("failed to decode YAML at %s:%w", file, err)
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &cfg); err != nil {
+		offset, ok := errorOffset(err)
+		if !ok {
+			return cfg, fmt.Errorf("failed to decode YAML in %s: %w", file, err)
+		}
+		pos := positions[0]
+		for _, p := range positions {
+			if p.offset >= offset {
+				break
+			}
+			pos = p
+		}
+		return cfg, fmt.Errorf("failed to decode YAML at %s:%d:%d: %w", file, pos.line, pos.column, err)
+	}
+	return cfg, nil
+}
+
+// yamlToJSON encodes a YAML node as JSON, recording the location of every node in the output.
+func yamlToJSON(buf *bytes.Buffer, node *yaml.Node, positions *[]position) error {
+	*positions = append(*positions, position{offset: int64(buf.Len()), line: node.Line, column: node.Column})
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlToJSON(buf, node.Alias, positions)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%d:%d: mapping keys must be scalars", key.Line, key.Column)
+			}
+			*positions = append(*positions, position{offset: int64(buf.Len()), line: key.Line, column: key.Column})
+			data, _ := json.Marshal(key.Value)
+			buf.Write(data)
+			buf.WriteByte(':')
+			if err := yamlToJSON(buf, node.Content[i+1], positions); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := yamlToJSON(buf, item, positions); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var val any = node.Value
+		if node.Tag != "!!str" {
+			if err := node.Decode(&val); err != nil {
+				return fmt.Errorf("%d:%d: %w", node.Line, node.Column, err)
+			}
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("%d:%d: %w", node.Line, node.Column, err)
+		}
+		buf.Write(data)
+	default:
+		return fmt.Errorf("%d:%d: unsupported YAML node", node.Line, node.Column)
+	}
+	return nil
+}
+
+func errorOffset(err error) (int64, bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset, true
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Offset, true
+	}
+	return 0, false
+}
+
+// lineColumn returns the 1-based line and column of the byte before offset, where decoding failed.
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > 0 {
+		offset--
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// stripJSONC blanks out comments and trailing commas, keeping offsets and newlines intact.
+func stripJSONC(data []byte) []byte {
+	out := bytes.Clone(data)
+
+	inString := false
+	lastComma := -1
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			lastComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end == -1 {
+				return out
+			}
+			for j := i; j < i+end+4; j++ {
+				if out[j] != '\n' {
+					out[j] = ' '
+				}
+			}
+			i += end + 3
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma != -1 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package config_test
+
+import (
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDecode_Formats(t *testing.T) {
+	want := types.Config{
+		Prefix: "gitlab_",
+		Tools: []types.Tool{{
+			Name:        "GetProject",
+			Description: "Gets a project.\nReturns its metadata.",
+			Args:        []types.Arg{{Name: "id", Type: "int", Required: true}},
+			Request: types.Request{
+				Host:            "gitlab.com",
+				Endpoint:        "/projects/:id",
+				Secure:          true,
+				PathParams:      []string{"id"},
+				MaxResponseSize: 1024,
+			},
+		}},
+	}
+
+	files := map[string]string{
+		"tools.json": `{
+  "prefix": "gitlab_",
+  "tools": [{
+    "name": "GetProject",
+    "description": "Gets a project.\nReturns its metadata.",
+    "args": [{"name": "id", "type": "int", "required": true}],
+    "request": {"host": "gitlab.com", "endpoint": "/projects/:id", "secure": true, "pathParams": ["id"], "maxResponseSize": 1024}
+  }]
+}`,
+		"tools.jsonc": `{
+  // Tools for the GitLab API.
+  "prefix": "gitlab_",
+  "tools": [{
+    "name": "GetProject", /* the tool name */
+    "description": "Gets a project.\nReturns its metadata.",
+    "args": [{"name": "id", "type": "int", "required": true,},],
+    "request": {
+      "host": "gitlab.com",
+      "endpoint": "/projects/:id", // "// not a comment"
+      "secure": true,
+      "pathParams": ["id"],
+      "maxResponseSize": 1024,
+    },
+  }],
+}`,
+		"tools.yaml": `
+prefix: gitlab_
+tools:
+  - name: GetProject
+    # Descriptions can span lines.
+    description: |-
+      Gets a project.
+      Returns its metadata.
+    args:
+      - {name: id, type: int, required: true}
+    request:
+      host: gitlab.com
+      endpoint: /projects/:id
+      secure: true
+      pathParams: [id]
+      maxResponseSize: 1024
+`,
+	}
+
+	for file, content := range files {
+		t.Run(file, func(t *testing.T) {
+			cfg, err := config.Decode(file, []byte(content))
+			require.NoError(t, err)
+			assert.Equal(t, want, cfg)
+		})
+	}
+}
+
+func TestDecode_BareArray(t *testing.T) {
+	cfg, err := config.Decode("tools.yml", []byte("- name: Ping\n  request: {host: example.com}\n"))
+	require.NoError(t, err)
+	require.Len(t, cfg.Tools, 1)
+	assert.Equal(t, "Ping", cfg.Tools[0].Name)
+}
+
+func TestDecode_NotConfig(t *testing.T) {
+	_, err := config.Decode("schema.json", []byte(`{"data": {"__schema": {}}}`))
+	assert.EqualError(t, err, "schema.json is not a config file: it has none of the keys prefix, apis, plugins, secrets, tools")
+
+	_, err = config.Decode("empty.yaml", nil)
+	assert.Error(t, err)
+
+	_, err = config.Decode("tools.jsonc", []byte("{\n  // shared\n  \"apis\": {},\n}"))
+	assert.NoError(t, err)
+}
+
+func TestDecode_ErrorLocations(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		want    string
+	}{
+		{
+			file:    "tools.json",
+			content: "[\n  {\"name\": \"Ping\",\n   \"args\": 5}\n]",
+			want:    "failed to decode JSON at tools.json:3:",
+		},
+		{
+			file:    "tools.json",
+			content: "[\n  {\"name\": \"Ping\",,}\n]",
+			want:    "failed to decode JSON at tools.json:2:19",
+		},
+		{
+			file:    "tools.jsonc",
+			content: "[\n  // comment\n  {\"name\": 5}\n]",
+			want:    "failed to decode JSONC at tools.jsonc:3:",
+		},
+		{
+			file:    "tools.yaml",
+			content: "- name: Ping\n  request:\n    secure: maybe\n",
+			want:    "failed to decode YAML at tools.yaml:3:13",
+		},
+		{
+			file:    "tools.yaml",
+			content: "- name: Ping\n  request: [\n",
+			want:    "failed to decode YAML at tools.yaml:2: did not find expected node content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := config.Decode(tt.file, []byte(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+// locate returns the location of every value in a config file keyed by its path, e.g.
+// tools[0].request.endpoint. The tools of a bare array config are keyed as tools[i] as well.
+func locate(file string, data []byte) map[string]position {
+	locations := make(map[string]position)
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+			return locations
+		}
+		root := doc.Content[0]
+		prefix := ""
+		if root.Kind == yaml.SequenceNode {
+			prefix = "tools"
+		}
+		locateYAML(root, prefix, locations)
+	default:
+		if strings.ToLower(filepath.Ext(file)) == ".jsonc" {
+			data = stripJSONC(data)
+		}
+		l := &jsonLocator{data: data, locations: locations}
+		i := l.skip(0)
+		prefix := ""
+		if i < len(data) && data[i] == '[' {
+			prefix = "tools"
+		}
+		l.value(i, prefix)
+	}
+	return locations
+}
+
+func locateYAML(node *yaml.Node, path string, locations map[string]position) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	locations[path] = position{line: node.Line, column: node.Column}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			locateYAML(node.Content[i+1], joinKey(path, node.Content[i].Value), locations)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			locateYAML(item, joinIndex(path, i), locations)
+		}
+	}
+}
+
+// jsonLocator scans JSON that is known to be valid, recording the location of every value.
+type jsonLocator struct {
+	data      []byte
+	locations map[string]position
+}
+
+// value records the value starting at or after offset i and returns the offset after it.
+func (l *jsonLocator) value(i int, path string) int {
+	i = l.skip(i)
+	if i >= len(l.data) {
+		return i
+	}
+	line, column := lineColumn(l.data, int64(i)+1)
+	l.locations[path] = position{offset: int64(i), line: line, column: column}
+
+	switch l.data[i] {
+	case '{':
+		i = l.skip(i + 1)
+		for i < len(l.data) && l.data[i] == '"' {
+			end := l.str(i)
+			var key string
+			_ = json.Unmarshal(l.data[i:end], &key)
+
+			i = l.skip(end)
+			if i < len(l.data) && l.data[i] == ':' {
+				i++
+			}
+			i = l.next(l.value(i, joinKey(path, key)))
+		}
+		return i + 1
+	case '[':
+		i = l.skip(i + 1)
+		for n := 0; i < len(l.data) && l.data[i] != ']'; n++ {
+			i = l.next(l.value(i, joinIndex(path, n)))
+		}
+		return i + 1
+	case '"':
+		return l.str(i)
+	default:
+		for i < len(l.data) && !strings.ContainsRune(",}] \t\r\n", rune(l.data[i])) {
+			i++
+		}
+		return i
+	}
+}
+
+// next skips the separator after a value.
+func (l *jsonLocator) next(i int) int {
+	i = l.skip(i)
+	if i < len(l.data) && l.data[i] == ',' {
+		i = l.skip(i + 1)
+	}
+	return i
+}
+
+// str returns the offset after the string starting at i.
+func (l *jsonLocator) str(i int) int {
+	for i++; i < len(l.data); i++ {
+		switch l.data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
+
+func (l *jsonLocator) skip(i int) int {
+	for i < len(l.data) && strings.ContainsRune(" \t\r\n", rune(l.data[i])) {
+		i++
+	}
+	return i
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func joinIndex(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
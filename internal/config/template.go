@@ -0,0 +1,294 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// value is the result of a pipeline stage. set is false for unset environment variables.
+type value struct {
+	s    string
+	set  bool
+	from string
+}
+
+// templateFunc is a pipeline function. in is nil for the first stage of a pipeline.
+type templateFunc func(arg string, in *value) (value, error)
+
+// Resolve expands the config placeholders in every string value of the config, e.g.
+// {{env TOKEN | required}} or {{file ./secrets/token | base64}}. Placeholders starting with another
+// function, like {{arg name}}, are left for later. Every failing placeholder is reported as Problems.
+func Resolve(src *Source) error {
+	funcs := templateFuncs(filepath.Dir(src.File))
+	escaped := make(map[string]placeholder.Func, len(funcs))
+	for name, fn := range funcs {
+		escaped[name] = func(arg string) (string, error) {
+			s, err := fn(arg)
+			return placeholder.Escape(s), err
+		}
+	}
+
+	v := &validator{source: *src}
+	resolve(reflect.ValueOf(&src.Config).Elem(), "", func(path, s string) string {
+		fns := funcs
+		if expandedLater(path) {
+			fns = escaped
+		}
+		out, err := placeholder.Expand(s, fns)
+		if err != nil {
+			if v.locations == nil {
+				v.locations = locate(src.File, src.Data)
+			}
+			v.addf(path, "%s", err)
+			return s
+		}
+		return out
+	})
+
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	return nil
+}
+
+// expandedLater reports whether the string at path is expanded again for each call, like headers, auth
+// credentials and workflow templates. The values resolved into it are escaped, so that a value holding
+// {{secret ...}} or {{arg ...}} is sent as-is.
+func expandedLater(path string) bool {
+	if strings.HasPrefix(path, "secrets.") {
+		return false
+	}
+	return strings.Contains(path, ".headers.") || strings.Contains(path, ".auth.") ||
+		strings.Contains(path, ".args.") || strings.HasSuffix(path, ".when") || strings.HasSuffix(path, ".output")
+}
+
+// resolve calls expand with every string held by v, replacing it with the result. Struct fields are
+// named by their JSON name in the path, and map entries are visited in key order.
+func resolve(v reflect.Value, path string, expand func(path, s string) string) {
+	switch v.Kind() {
+	case reflect.String:
+		if s := v.String(); strings.Contains(s, "{{") {
+			v.SetString(expand(path, s))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			resolve(v.Elem(), path, expand)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			elem := reflect.New(v.Elem().Type()).Elem()
+			elem.Set(v.Elem())
+			resolve(elem, path, expand)
+			v.Set(elem)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if !t.Field(i).IsExported() || name == "-" {
+				continue
+			}
+			resolve(v.Field(i), joinKey(path, name), expand)
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			resolve(v.Index(i), joinIndex(path, i), expand)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		for _, key := range keys {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			resolve(elem, joinKey(path, key.String()), expand)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
+
+// templateFuncs returns the functions a placeholder may start with. Each evaluates the whole pipeline of
+// the placeholder. Relative file paths are resolved against dir.
+func templateFuncs(dir string) map[string]placeholder.Func {
+	stages := map[string]templateFunc{
+		"env":      envFunc,
+		"required": requiredFunc,
+		"default":  defaultFunc,
+		"file": func(arg string, in *value) (value, error) {
+			path, err := input(arg, in)
+			if err != nil {
+				return value{}, fmt.Errorf("file: %w", err)
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return value{}, fmt.Errorf("file: %w", err)
+			}
+			return value{s: strings.TrimRight(string(data), "\r\n"), set: true, from: "file " + path}, nil
+		},
+		"base64": func(arg string, in *value) (value, error) {
+			s, err := input(arg, in)
+			if err != nil {
+				return value{}, fmt.Errorf("base64: %w", err)
+			}
+			return value{s: base64.StdEncoding.EncodeToString([]byte(s)), set: true}, nil
+		},
+		"uuid": func(arg string, in *value) (value, error) {
+			if arg != "" || in != nil {
+				return value{}, fmt.Errorf("uuid takes no input")
+			}
+			return value{s: uuid.NewString(), set: true}, nil
+		},
+		"now": func(arg string, in *value) (value, error) {
+			if in != nil {
+				return value{}, fmt.Errorf("now takes no input")
+			}
+			layout, err := unquote(arg)
+			if err != nil {
+				return value{}, fmt.Errorf("now: %w", err)
+			}
+			if layout == "" {
+				layout = time.RFC3339
+			}
+			return value{s: time.Now().UTC().Format(layout), set: true}, nil
+		},
+	}
+
+	funcs := make(map[string]placeholder.Func, len(stages))
+	for name := range stages {
+		if name == "default" {
+			continue
+		}
+		funcs[name] = func(rest string) (string, error) {
+			return runPipeline(stages, name+" "+rest)
+		}
+	}
+	return funcs
+}
+
+// runPipeline evaluates stages separated by "|", passing the value of each stage to the next.
+func runPipeline(stages map[string]templateFunc, pipeline string) (string, error) {
+	var in *value
+	for _, stage := range splitPipeline(pipeline) {
+		stage = strings.TrimSpace(stage)
+		name, arg, _ := strings.Cut(stage, " ")
+		fn, ok := stages[name]
+		if !ok {
+			return "", fmt.Errorf("unknown function %q", name)
+		}
+		out, err := fn(strings.TrimSpace(arg), in)
+		if err != nil {
+			return "", err
+		}
+		in = &out
+	}
+	return in.s, nil
+}
+
+// envFunc reads an environment variable. The legacy form {{env NAME:default}} falls back to the default
+// when the variable is unset or empty.
+func envFunc(arg string, in *value) (value, error) {
+	if in != nil {
+		return value{}, fmt.Errorf("env takes no input")
+	}
+
+	name, def, legacy := strings.Cut(arg, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return value{}, fmt.Errorf("env requires a variable name")
+	}
+
+	val, ok := os.LookupEnv(name)
+	if legacy && val == "" {
+		return value{s: strings.TrimSpace(def), set: true, from: "env " + name}, nil
+	}
+	return value{s: val, set: ok, from: "env " + name}, nil
+}
+
+// requiredFunc fails when its input, or the environment variable it names, is unset.
+func requiredFunc(arg string, in *value) (value, error) {
+	if in == nil {
+		out, err := envFunc(arg, nil)
+		if err != nil {
+			return value{}, fmt.Errorf("required: %w", err)
+		}
+		in = &out
+	}
+	if !in.set {
+		if in.from != "" {
+			return value{}, fmt.Errorf("%s is required but not set", in.from)
+		}
+		return value{}, fmt.Errorf("value is required but not set")
+	}
+	return *in, nil
+}
+
+// defaultFunc replaces an unset input with its argument.
+func defaultFunc(arg string, in *value) (value, error) {
+	if in == nil {
+		return value{}, fmt.Errorf("default requires an input")
+	}
+	if in.set {
+		return *in, nil
+	}
+	def, err := unquote(arg)
+	if err != nil {
+		return value{}, fmt.Errorf("default: %w", err)
+	}
+	return value{s: def, set: true}, nil
+}
+
+// input returns the argument of a stage, or its input when it has no argument.
+func input(arg string, in *value) (string, error) {
+	if arg != "" {
+		return unquote(arg)
+	}
+	if in == nil {
+		return "", fmt.Errorf("requires an argument or input")
+	}
+	return in.s, nil
+}
+
+// unquote returns a double quoted argument without its quotes, and other arguments as they are.
+func unquote(arg string) (string, error) {
+	if !strings.HasPrefix(arg, `"`) {
+		return arg, nil
+	}
+	s, err := strconv.Unquote(arg)
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted argument %s", arg)
+	}
+	return s, nil
+}
+
+// splitPipeline splits a pipeline at the "|" separators outside of double quotes.
+func splitPipeline(pipeline string) []string {
+	var stages []string
+	start, quoted := 0, false
+	for i := 0; i < len(pipeline); i++ {
+		switch pipeline[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case '|':
+			if !quoted {
+				stages = append(stages, pipeline[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(stages, pipeline[start:])
+}
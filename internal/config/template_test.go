@@ -0,0 +1,164 @@
+package config_test
+
+import (
+	"encoding/base64"
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("file-secret\n"), 0600))
+
+	t.Setenv("HOST_URL", "https://example.com")
+	t.Setenv("API_KEY", "secret_key")
+	t.Setenv("EMPTY", "")
+	t.Setenv("TOKEN_FILE", filepath.Join(dir, "token"))
+	t.Setenv("QUOTED", `a "quoted" \ value`)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "env var present, no default", input: "Connect to {{env HOST_URL}} now", expected: "Connect to https://example.com now"},
+		{name: "env var missing, default used", input: "API key is {{env MISSING_KEY:default_key}}", expected: "API key is default_key"},
+		{name: "env var missing, no default", input: "Value: {{env MISSING}}", expected: "Value: "},
+		{name: "multiple placeholders", input: "Host: {{env HOST_URL}}, Key: {{env API_KEY:default_key}}", expected: "Host: https://example.com, Key: secret_key"},
+		{name: "no placeholders", input: "Just a regular string", expected: "Just a regular string"},
+		{name: "malformed placeholder no closing braces", input: "Value {{env HOST_URL", expected: "Value {{env HOST_URL"},
+		{name: "placeholder with spaces and default", input: "URL: {{env   HOST_URL  :   https://default.com  }}", expected: "URL: https://example.com"},
+		{name: "placeholder with empty default", input: "Empty default {{env MISSING:}} end", expected: "Empty default  end"},
+		{name: "legacy default for empty value", input: "{{env EMPTY:fallback}}", expected: "fallback"},
+		{name: "value kept verbatim", input: "{{env QUOTED}}", expected: `a "quoted" \ value`},
+		{name: "default when unset", input: `{{env MISSING | default "a | b"}}`, expected: "a | b"},
+		{name: "default keeps empty value", input: "[{{env EMPTY | default fallback}}]", expected: "[]"},
+		{name: "required set", input: "{{required API_KEY}}", expected: "secret_key"},
+		{name: "required piped", input: "{{env EMPTY | required}}", expected: ""},
+		{name: "file relative to config", input: "{{file token}}", expected: "file-secret"},
+		{name: "file from env", input: "{{env TOKEN_FILE | file}}", expected: "file-secret"},
+		{name: "base64", input: "Basic {{env API_KEY | base64}}", expected: "Basic " + base64.StdEncoding.EncodeToString([]byte("secret_key"))},
+		{name: "base64 argument", input: `{{base64 "user:pass"}}`, expected: base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+		{name: "now with layout", input: `{{now "2006"}}`, expected: strconv.Itoa(time.Now().UTC().Year())},
+		{name: "runtime placeholders untouched", input: "{{arg id}} {{step first.id}} {{result first}}", expected: "{{arg id}} {{step first.id}} {{result first}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := config.Source{
+				File:   filepath.Join(dir, "tools.json"),
+				Config: types.Config{Tools: []types.Tool{{Name: "Ping", Request: types.Request{Host: tt.input}}}},
+			}
+			require.NoError(t, config.Resolve(&src))
+			assert.Equal(t, tt.expected, src.Config.Tools[0].Request.Host)
+		})
+	}
+}
+
+func TestResolve_AllStrings(t *testing.T) {
+	t.Setenv("TOKEN", "secret")
+
+	src := config.Source{
+		File: "tools.json",
+		Config: types.Config{
+			APIs: map[string]types.API{"gitlab": {Auth: &types.Auth{Type: "bearer", Token: "{{env TOKEN}}"}}},
+			Tools: []types.Tool{{
+				Name: "Ping",
+				Args: []types.Arg{{Name: "id", DefaultValue: "{{uuid}}"}},
+				Request: types.Request{
+					Headers:    map[string]string{"X-Token": "{{env TOKEN}}"},
+					PathParams: []string{"{{env TOKEN}}"},
+				},
+			}},
+		},
+	}
+	require.NoError(t, config.Resolve(&src))
+
+	assert.Equal(t, "secret", src.Config.APIs["gitlab"].Auth.Token)
+	assert.Equal(t, "secret", src.Config.Tools[0].Request.Headers["X-Token"])
+	assert.Equal(t, []string{"secret"}, src.Config.Tools[0].Request.PathParams)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f-]{36}$`), src.Config.Tools[0].Args[0].DefaultValue)
+}
+
+func TestResolve_EscapesValuesExpandedLater(t *testing.T) {
+	t.Setenv("TOKEN", `{{secret "x"}}`)
+
+	src := config.Source{
+		File: "tools.json",
+		Config: types.Config{
+			Secrets: map[string]types.SecretProvider{"vault": {Headers: map[string]string{"X-Token": "{{env TOKEN}}"}}},
+			Tools: []types.Tool{{
+				Name:    "Ping",
+				Request: types.Request{Host: "{{env TOKEN}}", Headers: map[string]string{"X-Token": "{{env TOKEN}} {{arg id}}"}},
+			}},
+		},
+	}
+	require.NoError(t, config.Resolve(&src))
+
+	header, err := placeholder.Expand(src.Config.Tools[0].Request.Headers["X-Token"], map[string]placeholder.Func{
+		"arg":    func(string) (string, error) { return "1", nil },
+		"secret": func(string) (string, error) { return "leaked", nil },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{{secret "x"}} 1`, header)
+	assert.Equal(t, `{{secret "x"}}`, src.Config.Tools[0].Request.Host)
+	assert.Equal(t, `{{secret "x"}}`, src.Config.Secrets["vault"].Headers["X-Token"])
+}
+
+func TestResolve_Errors(t *testing.T) {
+	data := `{
+  "tools": [{
+    "name": "Ping",
+    "request": {
+      "host": "{{required MISSING_HOST}}",
+      "headers": {"Authorization": "Bearer {{env MISSING_TOKEN | required}}"},
+      "endpoint": "{{file missing.txt}}"
+    }
+  }]
+}`
+	src := source("tools.json", data)
+
+	err := config.Resolve(&src)
+	var problems config.Problems
+	require.ErrorAs(t, err, &problems)
+
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	assert.ElementsMatch(t, []string{
+		`tools.json:5:15: tools[0].request.host: placeholder "{{required MISSING_HOST}}": env MISSING_HOST is required but not set`,
+		`tools.json:6:36: tools[0].request.headers.Authorization: placeholder "{{env MISSING_TOKEN | required}}": env MISSING_TOKEN is required but not set`,
+		`tools.json:7:19: tools[0].request.endpoint: placeholder "{{file missing.txt}}": file: open missing.txt: no such file or directory`,
+	}, lines)
+}
+
+func BenchmarkResolve(b *testing.B) {
+	b.Setenv("API_KEY", "live_key_123")
+
+	tools := make([]types.Tool, 1000)
+	for i := range tools {
+		tools[i] = types.Tool{
+			Name: "Tool" + strconv.Itoa(i),
+			Request: types.Request{
+				Host:    "{{env API_KEY:default_key}}",
+				Headers: map[string]string{"Authorization": "Bearer {{env API_KEY}}"},
+			},
+		}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		src := config.Source{File: "tools.json", Config: types.Config{Tools: append([]types.Tool(nil), tools...)}}
+		_ = config.Resolve(&src)
+	}
+}
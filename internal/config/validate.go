@@ -0,0 +1,314 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/rpc"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON Schema of config files.
+//
+//go:embed config.schema.json
+var Schema []byte
+
+// endpointParam matches the :name path params of an endpoint. Params start a path segment, so a
+// custom method suffix like /models/m:generate is not one.
+var endpointParam = regexp.MustCompile(`/:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Source is a decoded config file, with the data it was decoded from to locate problems.
+type Source struct {
+	File   string
+	Data   []byte
+	Config types.Config
+}
+
+// Problem is a mistake in a config file, located by the path of the offending value.
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.location() + ": " + p.Message
+	}
+	return fmt.Sprintf("%s: %s: %s", p.location(), p.Path, p.Message)
+}
+
+func (p Problem) location() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return p.File
+}
+
+// Problems is the error returned by Validate, listing every problem found.
+type Problems []Problem
+
+func (p Problems) Error() string {
+	lines := make([]string, 0, len(p)+1)
+	lines = append(lines, fmt.Sprintf("invalid config: %d problem(s) found", len(p)))
+	for _, problem := range p {
+		lines = append(lines, "  "+problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the configs for mistakes that would otherwise only surface when a tool is called:
+// endpoint params missing from pathParams, params, bodies and headers naming no arg, unknown arg types,
+// request kinds, APIs and secret providers, secrets used outside headers and auth, invalid rate limits and
+// caches, and duplicate tool names. Request kinds and arg types declared by plugins are known in addition
+// to the built-in kinds and argTypes; a nil argTypes skips the type check. The returned error is Problems.
+func Validate(sources []Source, argTypes []string) error {
+	v := &validator{
+		apis:     make(map[string]bool),
+		secrets:  make(map[string]string),
+		tools:    make(map[string]string),
+		argTypes: make(map[string]bool),
+		kinds:    map[string]bool{request.KindHTTP: true, "http": true, request.KindGraphQL: true, rpc.KindGRPC: true},
+	}
+
+	checkTypes := argTypes != nil
+	for _, t := range argTypes {
+		v.argTypes[t] = true
+	}
+	for _, src := range sources {
+		for name := range src.Config.APIs {
+			v.apis[name] = true
+		}
+		for _, p := range src.Config.Plugins {
+			for _, t := range p.ArgTypes {
+				v.argTypes[t] = true
+			}
+			for _, kind := range p.Kinds {
+				v.kinds[kind] = true
+			}
+		}
+	}
+	if !checkTypes {
+		v.argTypes = nil
+	}
+
+	for _, src := range sources {
+		v.source = src
+		v.locations = locate(src.File, src.Data)
+		for _, name := range slices.Sorted(maps.Keys(src.Config.Secrets)) {
+			path := joinKey("secrets", name)
+			if first, ok := v.secrets[name]; ok {
+				v.addf(path, "duplicate secret provider %q, first defined at %s", name, first)
+			} else {
+				v.secrets[name] = v.locate(path).location()
+			}
+		}
+	}
+
+	for _, src := range sources {
+		v.source = src
+		v.locations = locate(src.File, src.Data)
+		v.checkSecrets(src.Config)
+		for _, name := range slices.Sorted(maps.Keys(src.Config.APIs)) {
+			v.checkRateLimit(joinKey("apis", name)+".rateLimit", src.Config.APIs[name].RateLimit)
+		}
+		for i, t := range src.Config.Tools {
+			v.checkTool(joinIndex("tools", i), src.Config.Prefix, t)
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	return nil
+}
+
+type validator struct {
+	apis     map[string]bool
+	argTypes map[string]bool
+	kinds    map[string]bool
+	// secrets maps secret provider names to the location they were defined at.
+	secrets map[string]string
+	// tools maps tool names to the location they were first defined at.
+	tools map[string]string
+
+	source    Source
+	locations map[string]position
+	problems  Problems
+}
+
+func (v *validator) checkTool(path, prefix string, t types.Tool) {
+	if t.Name == "" {
+		v.addf(path, "tool name is required")
+	} else if first, ok := v.tools[prefix+t.Name]; ok {
+		v.addf(path+".name", "duplicate tool %q, first defined at %s", prefix+t.Name, first)
+	} else {
+		v.tools[prefix+t.Name] = v.locate(path + ".name").location()
+	}
+
+	v.checkRateLimit(path+".rateLimit", t.RateLimit)
+
+	args := make(map[string]bool, len(t.Args))
+	for i, arg := range t.Args {
+		argPath := joinIndex(path+".args", i)
+		switch {
+		case arg.Name == "":
+			v.addf(argPath, "arg name is required")
+		case args[arg.Name]:
+			v.addf(argPath+".name", "duplicate arg %q", arg.Name)
+		}
+		args[arg.Name] = true
+
+		if v.argTypes != nil && arg.Type != "" && !v.argTypes[arg.Type] {
+			v.addf(argPath+".type", "unknown arg type %q", arg.Type)
+		}
+	}
+
+	if len(t.Steps) == 0 {
+		v.checkRequest(path+".request", t.Request, args)
+		return
+	}
+	for i, step := range t.Steps {
+		stepArgs := make(map[string]bool, len(args)+len(step.Args))
+		for name := range args {
+			stepArgs[name] = true
+		}
+		for name := range step.Args {
+			stepArgs[name] = true
+		}
+		v.checkRequest(joinIndex(path+".steps", i)+".request", step.Request, stepArgs)
+	}
+}
+
+func (v *validator) checkRequest(path string, req types.Request, args map[string]bool) {
+	if !v.kinds[req.Kind] {
+		v.addf(path+".kind", "unknown request kind %q", req.Kind)
+	}
+	if req.API != "" && !v.apis[req.API] {
+		v.addf(path+".api", "unknown api %q", req.API)
+	}
+
+	for _, m := range endpointParam.FindAllStringSubmatch(req.Endpoint, -1) {
+		if !slices.Contains(req.PathParams, m[1]) {
+			v.addf(path+".endpoint", "endpoint param %q is missing from pathParams", ":"+m[1])
+		}
+	}
+	for i, param := range req.PathParams {
+		if !strings.Contains(req.Endpoint, ":"+param) {
+			v.addf(joinIndex(path+".pathParams", i), "path param %q does not appear in endpoint", param)
+		}
+	}
+
+	v.checkArgs(path+".pathParams", "path param", req.PathParams, args)
+	v.checkArgs(path+".queryParams", "query param", req.QueryParams, args)
+	v.checkArgs(path+".headerParams", "header param", req.HeaderParams, args)
+	v.checkArgs(path+".bodyParams", "body param", req.BodyParams, args)
+	if req.GraphQL != nil {
+		v.checkArgs(path+".graphql.variables", "graphql variable", req.GraphQL.Variables, args)
+	}
+	if req.GRPC != nil {
+		v.checkArgs(path+".grpc.fields", "grpc field", req.GRPC.Fields, args)
+	}
+
+	if req.Cache != nil {
+		if method := strings.ToUpper(req.Method); method != "" && method != "GET" && method != "HEAD" {
+			v.addf(path+".cache", "cache requires a GET or HEAD request, not %s", method)
+		}
+		if request.NormalizeKind(req.Kind) != request.KindHTTP {
+			v.addf(path+".cache", "cache is only supported for http requests")
+		}
+		if _, err := time.ParseDuration(req.Cache.TTL); req.Cache.TTL != "" && err != nil {
+			v.addf(path+".cache.ttl", "invalid ttl %q", req.Cache.TTL)
+		}
+	}
+
+	if req.Body != "" && !args[req.Body] {
+		v.addf(path+".body", "body names unknown arg %q", req.Body)
+	}
+	for i, f := range req.Files {
+		if !args[f.Arg] {
+			v.addf(joinIndex(path+".files", i)+".arg", "file part names unknown arg %q", f.Arg)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(req.Headers)) {
+		_, _ = placeholder.Expand(req.Headers[name], map[string]placeholder.Func{
+			"arg": func(arg string) (string, error) {
+				if !args[arg] {
+					v.addf(joinKey(path+".headers", name), "header %q references unknown arg %q", name, arg)
+				}
+				return "", nil
+			},
+		})
+	}
+}
+
+// checkSecrets checks that secret placeholders name a provider and are only used in the headers and auth
+// credentials of requests and APIs, where they are resolved.
+func (v *validator) checkSecrets(cfg types.Config) {
+	resolve(reflect.ValueOf(&cfg).Elem(), "", func(path, s string) string {
+		_, _ = placeholder.Expand(s, map[string]placeholder.Func{
+			"secret": func(arg string) (string, error) {
+				name, _, _ := strings.Cut(strings.TrimSpace(arg), " ")
+				switch {
+				case strings.HasPrefix(path, "secrets.") || !(strings.Contains(path, ".headers.") || strings.Contains(path, ".auth.")):
+					v.addf(path, "secrets are only resolved in headers and auth")
+				case v.secrets[name] == "":
+					v.addf(path, "unknown secret provider %q", name)
+				}
+				return "", nil
+			},
+		})
+		return s
+	})
+}
+
+func (v *validator) checkRateLimit(path, limit string) {
+	if limit == "" {
+		return
+	}
+	if _, err := ratelimit.Parse(limit); err != nil {
+		v.addf(path, "%s", err)
+	}
+}
+
+func (v *validator) checkArgs(path, what string, names []string, args map[string]bool) {
+	for i, name := range names {
+		if !args[name] {
+			v.addf(joinIndex(path, i), "%s %q has no matching arg", what, name)
+		}
+	}
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	problem := v.locate(path)
+	problem.Message = fmt.Sprintf(format, args...)
+	v.problems = append(v.problems, problem)
+}
+
+// locate returns a problem at path, located at the value or, when it is missing from the file, at its
+// closest parent.
+func (v *validator) locate(path string) Problem {
+	problem := Problem{File: v.source.File, Path: path}
+	for p := path; ; {
+		if pos, ok := v.locations[p]; ok {
+			problem.Line, problem.Column = pos.line, pos.column
+			return problem
+		}
+		i := strings.LastIndexAny(p, ".[")
+		if i <= 0 {
+			return problem
+		}
+		p = p[:i]
+	}
+}
@@ -0,0 +1,277 @@
+package config_test
+
+import (
+	"encoding/json"
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var builtinTypes = []string{"bool", "float", "int", "string"}
+
+func TestValidate_Problems(t *testing.T) {
+	data := `{
+  "prefix": "gl_",
+  "tools": [
+    {
+      "name": "GetItem",
+      "args": [{"name": "id", "type": "integer"}, {"name": "id"}],
+      "request": {
+        "api": "gitlab",
+        "endpoint": "/items/:id/notes/:note",
+        "pathParams": ["id", "other"],
+        "queryParams": ["q"],
+        "body": "payload",
+        "headers": {"X-Tenant": "{{arg tenant}}"}
+      }
+    },
+    {"name": "GetItem", "request": {"endpoint": "/"}}
+  ]
+}`
+
+	problems := validate(t, builtinTypes, source("tools.json", data))
+
+	assert.Equal(t, []string{
+		`tools.json:6:39: tools[0].args[0].type: unknown arg type "integer"`,
+		`tools.json:6:60: tools[0].args[1].name: duplicate arg "id"`,
+		`tools.json:8:16: tools[0].request.api: unknown api "gitlab"`,
+		`tools.json:9:21: tools[0].request.endpoint: endpoint param ":note" is missing from pathParams`,
+		`tools.json:10:30: tools[0].request.pathParams[1]: path param "other" does not appear in endpoint`,
+		`tools.json:10:30: tools[0].request.pathParams[1]: path param "other" has no matching arg`,
+		`tools.json:11:25: tools[0].request.queryParams[0]: query param "q" has no matching arg`,
+		`tools.json:12:17: tools[0].request.body: body names unknown arg "payload"`,
+		`tools.json:13:33: tools[0].request.headers.X-Tenant: header "X-Tenant" references unknown arg "tenant"`,
+		`tools.json:16:14: tools[1].name: duplicate tool "gl_GetItem", first defined at tools.json:5:15`,
+	}, problems)
+}
+
+func TestValidate_Locations(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+		want string
+	}{
+		{
+			file: "tools.json",
+			data: "[\n  {\"name\": \"Ping\", \"request\": {\"endpoint\": \"/:id\"}}\n]",
+			want: "tools.json:2:44: tools[0].request.endpoint",
+		},
+		{
+			file: "tools.jsonc",
+			data: "// Tools\n[\n  {\"name\": \"Ping\", /* no params */ \"request\": {\"endpoint\": \"/:id\",},},\n]",
+			want: "tools.jsonc:3:60: tools[0].request.endpoint",
+		},
+		{
+			file: "tools.yaml",
+			data: "tools:\n  - name: Ping\n    request:\n      endpoint: /:id\n",
+			want: "tools.yaml:4:17: tools[0].request.endpoint",
+		},
+		{
+			file: "tools.yml",
+			data: "- name: Ping\n  request: {host: example.com}\n  args: [{name: id, type: uuid}]\n",
+			want: "tools.yml:3:27: tools[0].args[0].type",
+		},
+		{
+			file: "tools.yaml",
+			data: "- name: Ping\n  request:\n    pathParams: [id]\n",
+			want: "tools.yaml:3:18: tools[0].request.pathParams[0]",
+		},
+		{
+			file: "tools.json",
+			data: `[{"name": "Ping", "steps": [{"name": "first", "request": {"endpoint": "/:id"}}]}]`,
+			want: "tools.json:1:71: tools[0].steps[0].request.endpoint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			problems := validate(t, builtinTypes, source(tt.file, tt.data))
+			require.NotEmpty(t, problems)
+			assert.True(t, strings.HasPrefix(problems[0], tt.want+": "), problems[0])
+		})
+	}
+}
+
+func TestValidate_MissingValueLocatedAtParent(t *testing.T) {
+	problems := validate(t, builtinTypes, source("tools.json", `[{"name": "Ping", "request": {"endpoint": "/:id"}}]`),
+		source("other.json", `[{"name": "Ping", "request": {}}]`))
+
+	assert.Equal(t, []string{
+		`tools.json:1:43: tools[0].request.endpoint: endpoint param ":id" is missing from pathParams`,
+		`other.json:1:11: tools[0].name: duplicate tool "Ping", first defined at tools.json:1:11`,
+	}, problems)
+}
+
+func TestValidate_Valid(t *testing.T) {
+	data := `{
+  "apis": {"gitlab": {"baseURL": "https://gitlab.com/api/v4"}},
+  "plugins": [{"name": "ids", "path": "ids.wasm", "argTypes": ["ulid"]}],
+  "tools": [
+    {
+      "name": "GetNote",
+      "args": [{"name": "id", "type": "ulid"}, {"name": "tenant"}, {"name": "note", "type": "int"}],
+      "request": {
+        "api": "gitlab",
+        "endpoint": "/models/m:generate/:id/notes/:note",
+        "pathParams": ["id", "note"],
+        "headers": {"X-Tenant": "{{arg tenant}}", "X-Client": "{{env CLIENT}}"}
+      }
+    },
+    {
+      "name": "CreateNote",
+      "args": [{"name": "id"}],
+      "steps": [
+        {"name": "item", "request": {"endpoint": "/items/:id", "pathParams": ["id"]}, "extract": {"ref": "body.ref"}},
+        {"name": "note", "args": {"ref": "{{step item.ref}}"}, "request": {"endpoint": "/notes/:ref", "pathParams": ["ref"]}}
+      ]
+    }
+  ]
+}`
+
+	assert.Empty(t, validate(t, builtinTypes, source("tools.json", data)))
+}
+
+func TestValidate_Secrets(t *testing.T) {
+	data := `{
+  "secrets": {"vault": {"type": "http", "url": "https://vault", "headers": {"X-Token": "{{secret vault root}}"}}},
+  "apis": {"gitlab": {"auth": {"type": "bearer", "token": "{{secret vault gitlab}}"}}},
+  "tools": [{
+    "name": "Ping",
+    "request": {
+      "host": "{{secret vault host}}",
+      "headers": {"X-Key": "{{secret missing key}}"}
+    }
+  }]
+}`
+
+	problems := validate(t, builtinTypes, source("tools.json", data),
+		source("other.json", `{"secrets": {"vault": {"type": "docker"}}, "tools": []}`))
+
+	assert.Equal(t, []string{
+		`other.json:1:23: secrets.vault: duplicate secret provider "vault", first defined at tools.json:2:24`,
+		`tools.json:2:88: secrets.vault.headers.X-Token: secrets are only resolved in headers and auth`,
+		`tools.json:7:15: tools[0].request.host: secrets are only resolved in headers and auth`,
+		`tools.json:8:28: tools[0].request.headers.X-Key: unknown secret provider "missing"`,
+	}, problems)
+}
+
+func TestValidate_RateLimits(t *testing.T) {
+	data := "apis:\n  gitlab: {baseURL: https://gitlab.com, rateLimit: fast}\n" +
+		"tools:\n  - name: Ping\n    rateLimit: 0/s\n  - name: Pong\n    rateLimit: 5/10s\n"
+
+	assert.Equal(t, []string{
+		`tools.yaml:2:52: apis.gitlab.rateLimit: invalid rate limit "fast": expected count/period, e.g. 10/s`,
+		`tools.yaml:5:16: tools[0].rateLimit: invalid rate limit "0/s": count must be a positive integer`,
+	}, validate(t, builtinTypes, source("tools.yaml", data)))
+}
+
+func TestValidate_Cache(t *testing.T) {
+	data := "tools:\n  - name: Create\n    request: {method: POST, cache: {ttl: soon}}\n" +
+		"  - name: List\n    request: {method: GET, cache: {ttl: 5m}}\n"
+
+	assert.Equal(t, []string{
+		`tools.yaml:3:36: tools[0].request.cache: cache requires a GET or HEAD request, not POST`,
+		`tools.yaml:3:42: tools[0].request.cache.ttl: invalid ttl "soon"`,
+	}, validate(t, builtinTypes, source("tools.yaml", data)))
+}
+
+func TestValidate_Kinds(t *testing.T) {
+	data := "plugins: [{name: ids, path: ids.wasm, kinds: [ulid]}]\n" +
+		"tools:\n  - name: Get\n    request: {kind: http, method: GET, cache: {ttl: 5m}}\n" +
+		"  - name: Query\n    request: {kind: graphql}\n" +
+		"  - name: Call\n    request: {kind: grpc}\n" +
+		"  - name: Next\n    request: {kind: ulid}\n" +
+		"  - name: Send\n    request: {kind: soap}\n"
+
+	src := source("tools.yaml", data)
+	assert.Equal(t, "", src.Config.Tools[0].Request.Kind)
+	assert.Equal(t, []string{
+		`tools.yaml:12:21: tools[4].request.kind: unknown request kind "soap"`,
+	}, validate(t, builtinTypes, src))
+}
+
+func TestValidate_NilArgTypesSkipsTypeCheck(t *testing.T) {
+	src := source("tools.json", `[{"name": "Ping", "args": [{"name": "id", "type": "custom"}]}]`)
+
+	assert.Empty(t, validate(t, nil, src))
+	assert.Equal(t, []string{`tools.json:1:51: tools[0].args[0].type: unknown arg type "custom"`}, validate(t, builtinTypes, src))
+}
+
+// TestSchema_MatchesTypes checks that the JSON Schema describes exactly the fields of the config types.
+func TestSchema_MatchesTypes(t *testing.T) {
+	var schema struct {
+		OneOf []struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"oneOf"`
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(config.Schema, &schema))
+
+	defs := map[string]reflect.Type{
+		"tool":     reflect.TypeOf(types.Tool{}),
+		"arg":      reflect.TypeOf(types.Arg{}),
+		"request":  reflect.TypeOf(types.Request{}),
+		"step":     reflect.TypeOf(types.Step{}),
+		"batch":    reflect.TypeOf(types.Batch{}),
+		"hook":     reflect.TypeOf(types.Hook{}),
+		"graphql":  reflect.TypeOf(types.GraphQLRequest{}),
+		"grpc":     reflect.TypeOf(types.GRPCRequest{}),
+		"filePart": reflect.TypeOf(types.FilePart{}),
+		"api":      reflect.TypeOf(types.API{}),
+		"auth":     reflect.TypeOf(types.Auth{}),
+		"retry":    reflect.TypeOf(types.Retry{}),
+		"plugin":   reflect.TypeOf(types.Plugin{}),
+
+		"secretProvider": reflect.TypeOf(types.SecretProvider{}),
+	}
+	for name, typ := range defs {
+		assert.ElementsMatch(t, jsonFields(typ), slices.Collect(maps.Keys(schema.Defs[name].Properties)), name)
+	}
+
+	config := slices.DeleteFunc(slices.Collect(maps.Keys(schema.OneOf[1].Properties)), func(s string) bool { return s == "$schema" })
+	assert.ElementsMatch(t, jsonFields(reflect.TypeOf(types.Config{})), config, "config")
+}
+
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func source(file, data string) config.Source {
+	cfg, err := config.Decode(file, []byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return config.Source{File: file, Data: []byte(data), Config: cfg}
+}
+
+func validate(t *testing.T, argTypes []string, sources ...config.Source) []string {
+	t.Helper()
+
+	err := config.Validate(sources, argTypes)
+	if err == nil {
+		return nil
+	}
+
+	var problems config.Problems
+	require.ErrorAs(t, err, &problems)
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = p.String()
+	}
+	return lines
+}
@@ -0,0 +1,350 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
+	"github.com/AdamShannag/api-mcp-server/pkg/graphql"
+	"github.com/AdamShannag/api-mcp-server/pkg/plugin"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/resolver"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
+	"github.com/AdamShannag/api-mcp-server/pkg/tool"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/server"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+type Option func(*Loader)
+
+// Loader loads the tools of config files, with their plugins, secret providers and rate limits.
+type Loader struct {
+	paths []string
+
+	pluginOpts []plugin.Option
+	plugins    []*plugin.Plugin
+
+	secrets    *secret.Store
+	secretOpts []secret.HTTPOption
+
+	rateLimiter *ratelimit.Limiter
+}
+
+// New creates a loader of the config files, directories or glob patterns.
+func New(paths []string, opts ...Option) *Loader {
+	l := &Loader{paths: paths}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// WithPluginOptions sets the options used to load plugins.
+func WithPluginOptions(opts ...plugin.Option) Option {
+	return func(l *Loader) {
+		l.pluginOpts = append(l.pluginOpts, opts...)
+	}
+}
+
+// WithSecrets sets the store the secret providers of config files are registered with. It should be the
+// store of the executor.
+func WithSecrets(store *secret.Store, opts ...secret.HTTPOption) Option {
+	return func(l *Loader) {
+		l.secrets = store
+		l.secretOpts = append(l.secretOpts, opts...)
+	}
+}
+
+// WithRateLimiter sets the limiter the rate limits of tools and APIs are registered with.
+func WithRateLimiter(r *ratelimit.Limiter) Option {
+	return func(l *Loader) {
+		l.rateLimiter = r
+	}
+}
+
+// Load loads the tools of all config files and registers them with the MCP server. Tool and API names must
+// be unique across files, and tools may reference APIs from any file. Nothing is loaded when the configs
+// fail validation.
+func (l *Loader) Load(manager *tool.Manager, s *server.MCPServer) error {
+	sources, err := l.readConfigs()
+	if err != nil {
+		return err
+	}
+	if err = config.Validate(sources, manager.ArgTypes()); err != nil {
+		return err
+	}
+
+	var tools []types.Tool
+	apis := make(map[string]types.API)
+	locations := make(map[string]string)
+	apiLocations := make(map[string]string)
+	for _, src := range sources {
+		cfg, err := l.loadConfig(manager, src)
+		if err != nil {
+			return err
+		}
+
+		for name, api := range cfg.APIs {
+			if prev, ok := apiLocations[name]; ok {
+				return fmt.Errorf("duplicate api %q defined in %s and %s", name, prev, src.File)
+			}
+			apiLocations[name] = src.File
+			apis[name] = api
+		}
+		for _, t := range cfg.Tools {
+			if prev, ok := locations[t.Name]; ok {
+				return fmt.Errorf("duplicate tool %q defined in %s and %s", t.Name, prev, src.File)
+			}
+			locations[t.Name] = src.File
+		}
+		tools = append(tools, cfg.Tools...)
+	}
+
+	for i := range tools {
+		if err = config.ApplyAPIs(apis, &tools[i]); err != nil {
+			return fmt.Errorf("tool %q in %s: %w", tools[i].Name, locations[tools[i].Name], err)
+		}
+	}
+
+	if err = l.setRateLimits(apis, tools); err != nil {
+		return err
+	}
+
+	for _, t := range tools {
+		if err = manager.AddTool(s, t); err != nil {
+			return err
+		}
+	}
+	manager.AddContinuationTool(s)
+
+	slog.Info("tools loaded", slog.Int("count", len(tools)), slog.Int("files", len(sources)))
+	return nil
+}
+
+// Validate checks the config files without loading them, reporting every problem found as config.Problems.
+// Arg types are checked against the built-in types and those declared by plugins.
+func (l *Loader) Validate() error {
+	sources, err := l.readConfigs()
+	if err != nil {
+		return err
+	}
+	return config.Validate(sources, resolver.NewDefaultTypeResolverRegistry().Types())
+}
+
+// Close closes the loaded plugins.
+func (l *Loader) Close(ctx context.Context) {
+	for _, p := range l.plugins {
+		if err := p.Close(ctx); err != nil {
+			slog.Error("failed to close plugin", slog.String("plugin", p.Name()), slog.String("error", err.Error()))
+		}
+	}
+	l.plugins = nil
+}
+
+// setRateLimits registers the upstream hosts and rate limits of the tools and the rate limits of the APIs
+// with the rate limiter.
+func (l *Loader) setRateLimits(apis map[string]types.API, tools []types.Tool) error {
+	if l.rateLimiter == nil {
+		return nil
+	}
+
+	for name, api := range apis {
+		if api.RateLimit == "" {
+			continue
+		}
+		limit, err := ratelimit.Parse(api.RateLimit)
+		if err != nil {
+			return fmt.Errorf("api %q: %w", name, err)
+		}
+		u, err := url.Parse(api.BaseURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("api %q: rate limit requires a baseURL with a host", name)
+		}
+		l.rateLimiter.SetHost(u.Host, limit)
+	}
+
+	for _, t := range tools {
+		var limit *ratelimit.Limit
+		if t.RateLimit != "" {
+			rl, err := ratelimit.Parse(t.RateLimit)
+			if err != nil {
+				return fmt.Errorf("tool %q: %w", t.Name, err)
+			}
+			limit = &rl
+		}
+
+		hosts := []string{t.Request.Host}
+		for _, step := range t.Steps {
+			hosts = append(hosts, step.Request.Host)
+		}
+		slices.Sort(hosts)
+		hosts = slices.DeleteFunc(slices.Compact(hosts), func(host string) bool { return host == "" })
+		l.rateLimiter.SetTool(t.Name, limit, hosts...)
+		if t.Batch != nil {
+			l.rateLimiter.SetBatchTool(tool.BatchName(t.Name), t.Name)
+		}
+	}
+	return nil
+}
+
+// readConfigs reads and decodes the config files, resolving their placeholders.
+func (l *Loader) readConfigs() ([]config.Source, error) {
+	files, err := config.Expand(l.paths)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]config.Source, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		cfg, err := config.Decode(file, data)
+		if err != nil {
+			return nil, err
+		}
+
+		src := config.Source{File: file, Data: data, Config: cfg}
+		if err = config.Resolve(&src); err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// loadConfig loads the plugins and secret providers of a config file. Relative paths in its tools are resolved against the file
+// and their names are prefixed with the file prefix.
+func (l *Loader) loadConfig(manager *tool.Manager, src config.Source) (types.Config, error) {
+	cfg := src.Config
+	if err := l.loadPlugins(manager, src.File, cfg.Plugins); err != nil {
+		return cfg, err
+	}
+	if err := l.loadSecrets(src.File, cfg.Secrets); err != nil {
+		return cfg, err
+	}
+
+	var err error
+	if cfg.Tools, err = expandGraphQLTools(src.File, cfg.Tools); err != nil {
+		return cfg, err
+	}
+	resolvePaths(src.File, cfg.Tools)
+
+	for i := range cfg.Tools {
+		cfg.Tools[i].Name = cfg.Prefix + cfg.Tools[i].Name
+	}
+	return cfg, nil
+}
+
+// loadPlugins loads the WebAssembly plugins and registers their arg types and request kinds with the manager.
+// Relative plugin paths are resolved against the config file.
+func (l *Loader) loadPlugins(manager *tool.Manager, file string, plugins []types.Plugin) error {
+	ctx := context.Background()
+	for _, cfg := range plugins {
+		cfg.Path = resolvePath(file, cfg.Path)
+
+		p, err := plugin.Load(ctx, cfg, l.pluginOpts...)
+		if err != nil {
+			return err
+		}
+		l.plugins = append(l.plugins, p)
+
+		for _, argType := range p.ArgTypes() {
+			if err = manager.RegisterArgType(argType, p.ArgResolver()); err != nil {
+				return fmt.Errorf("plugin %s: %w", p.Name(), err)
+			}
+		}
+		for _, kind := range p.Kinds() {
+			if err = manager.RegisterKind(kind, p.Executor()); err != nil {
+				return fmt.Errorf("plugin %s: %w", p.Name(), err)
+			}
+		}
+	}
+
+	if len(plugins) > 0 {
+		slog.Info("plugins loaded", slog.Int("count", len(plugins)))
+	}
+	return nil
+}
+
+// loadSecrets registers the secret providers of a config file with the secret store. Relative provider paths
+// are resolved against the config file.
+func (l *Loader) loadSecrets(file string, providers map[string]types.SecretProvider) error {
+	if len(providers) == 0 {
+		return nil
+	}
+	if l.secrets == nil {
+		return fmt.Errorf("secret providers in %s require a secret store", file)
+	}
+
+	for name, cfg := range providers {
+		if cfg.Path != "" {
+			cfg.Path = resolvePath(file, cfg.Path)
+		}
+
+		p, ttl, err := secret.Load(cfg, l.secretOpts...)
+		if err != nil {
+			return fmt.Errorf("secret provider %q: %w", name, err)
+		}
+		l.secrets.Register(name, p, ttl)
+	}
+
+	slog.Info("secret providers loaded", slog.Int("count", len(providers)))
+	return nil
+}
+
+// expandGraphQLTools replaces graphql tools that declare an introspection file, instead of a query,
+// with one generated tool per query and mutation. Relative paths are resolved against the config file.
+func expandGraphQLTools(file string, tools []types.Tool) ([]types.Tool, error) {
+	expanded := make([]types.Tool, 0, len(tools))
+	for _, t := range tools {
+		gql := t.Request.GraphQL
+		if t.Request.Kind != request.KindGraphQL || gql == nil || gql.Introspection == "" || gql.Query != "" {
+			expanded = append(expanded, t)
+			continue
+		}
+
+		data, err := os.ReadFile(resolvePath(file, gql.Introspection))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read introspection file: %w", err)
+		}
+
+		generated, err := graphql.ToolsFromIntrospection(data, t)
+		if err != nil {
+			return nil, fmt.Errorf("tool %q: %w", t.Name, err)
+		}
+		expanded = append(expanded, generated...)
+	}
+	return expanded, nil
+}
+
+// resolvePaths resolves relative gRPC protoset and hook file paths against the config file.
+func resolvePaths(file string, tools []types.Tool) {
+	for _, t := range tools {
+		if g := t.Request.GRPC; g != nil {
+			g.Protoset = resolvePath(file, g.Protoset)
+		}
+		for _, h := range []*types.Hook{t.PreRequest, t.PostResponse} {
+			if h != nil {
+				h.File = resolvePath(file, h.File)
+			}
+		}
+	}
+}
+
+// resolvePath resolves a path relative to the config file.
+func resolvePath(file, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(file), path)
+}
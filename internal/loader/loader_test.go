@@ -0,0 +1,38 @@
+package loader
+
+import (
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandGraphQLTools(t *testing.T) {
+	tmpDir := t.TempDir()
+	toolsFile := filepath.Join(tmpDir, "tools.json")
+
+	schema := `{"__schema":{"queryType":{"name":"Query"},"types":[{"kind":"OBJECT","name":"Query","fields":[
+		{"name":"ping","args":[],"type":{"kind":"SCALAR","name":"String"}},
+		{"name":"echo","args":[{"name":"msg","type":{"kind":"SCALAR","name":"String"}}],"type":{"kind":"SCALAR","name":"String"}}
+	]}]}}`
+	_ = os.WriteFile(filepath.Join(tmpDir, "schema.json"), []byte(schema), 0644)
+
+	tools, err := expandGraphQLTools(toolsFile, []types.Tool{
+		{Name: "Plain", Request: types.Request{Host: "example.com"}},
+		{Name: "api_", Request: types.Request{Kind: "graphql", GraphQL: &types.GraphQLRequest{Introspection: "schema.json"}}},
+	})
+	assert.NoError(t, err)
+
+	var names []string
+	for _, tl := range tools {
+		names = append(names, tl.Name)
+	}
+	assert.Equal(t, []string{"Plain", "api_ping", "api_echo"}, names)
+
+	_, err = expandGraphQLTools(toolsFile, []types.Tool{
+		{Name: "api_", Request: types.Request{Kind: "graphql", GraphQL: &types.GraphQLRequest{Introspection: "missing.json"}}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read introspection file")
+}
@@ -2,15 +2,17 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/AdamShannag/api-mcp-server/internal/auth"
+	"github.com/AdamShannag/api-mcp-server/internal/loader"
 	"github.com/AdamShannag/api-mcp-server/internal/middleware"
 	"github.com/AdamShannag/api-mcp-server/internal/monitoring"
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
+	"github.com/AdamShannag/api-mcp-server/pkg/plugin"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
+	apiserver "github.com/AdamShannag/api-mcp-server/pkg/server"
 	"github.com/AdamShannag/api-mcp-server/pkg/tool"
-	"github.com/AdamShannag/api-mcp-server/pkg/types"
-	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"golang.org/x/sync/errgroup"
 	"log/slog"
@@ -18,14 +20,13 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
-	"strings"
 	"syscall"
 	"time"
 )
 
 const (
-	Version        = "0.2.0"
-	serverName     = "API MCP Server"
+	Version        = apiserver.Version
+	serverName     = apiserver.Name
 	defaultSseHost = "127.0.0.1"
 	defaultSsePort = 13080
 )
@@ -33,14 +34,22 @@ const (
 type ServerOption func(*Server)
 
 type Server struct {
-	server        *server.MCPServer
-	transport     string
-	toolsFilePath string
-	host          string
-	port          string
+	server         *server.MCPServer
+	transport      string
+	toolsFilePaths []string
+	host           string
+	port           string
 
 	auth    *auth.Authenticator
 	httpSrv *http.Server
+
+	pluginOpts []plugin.Option
+
+	secrets    *secret.Store
+	secretOpts []secret.HTTPOption
+
+	rateLimiter  *ratelimit.Limiter
+	configLoader *loader.Loader
 }
 
 func NewServer(transport string, opts ...ServerOption) *Server {
@@ -58,13 +67,16 @@ func NewServer(transport string, opts ...ServerOption) *Server {
 		server.WithLogging(),
 		server.WithRecovery(),
 		server.WithToolCapabilities(true),
-		server.WithHooks(s.getHooks()),
+		server.WithHooks(monitoring.AddHooks(&server.Hooks{})),
 	}
 
 	if s.auth != nil {
 		options = append(options, server.WithToolHandlerMiddleware(s.auth.Middleware()))
 		options = append(options, server.WithToolHandlerMiddleware(middleware.LoggingMiddleware))
 	}
+	if s.rateLimiter != nil {
+		options = append(options, server.WithToolHandlerMiddleware(middleware.RateLimitMiddleware(s.rateLimiter)))
+	}
 
 	s.server = server.NewMCPServer(
 		serverName,
@@ -76,6 +88,8 @@ func NewServer(transport string, opts ...ServerOption) *Server {
 }
 
 func (s *Server) Run() error {
+	defer s.loader().Close(context.Background())
+
 	if s.httpSrv != nil && s.transport != "stdio" {
 		go func() {
 			slog.Info("metrics server started", slog.String("addr", s.httpSrv.Addr))
@@ -93,24 +107,29 @@ func (s *Server) Run() error {
 	}
 }
 
+// LoadTools loads the tools of all config files, resolved from the configured paths, directories and glob
+// patterns. Tool and API names must be unique across files, and tools may reference APIs from any file.
+// Nothing is loaded when the configs fail validation.
 func (s *Server) LoadTools(manager *tool.Manager) error {
-	data, err := os.ReadFile(s.toolsFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
+	return s.loader().Load(manager, s.server)
+}
 
-	var tools []types.Tool
-	decoder := json.NewDecoder(strings.NewReader(s.resolveEnvPlaceholders(string(data))))
-	if err = decoder.Decode(&tools); err != nil {
-		return fmt.Errorf("failed to decode JSON: %w", err)
-	}
+// ValidateTools checks the config files without loading them, reporting every problem found as
+// config.Problems. Arg types are checked against the built-in types and those declared by plugins.
+func (s *Server) ValidateTools() error {
+	return s.loader().Validate()
+}
 
-	for _, t := range tools {
-		manager.AddTool(s.server, t)
+// loader returns the loader of the config files, created on first use.
+func (s *Server) loader() *loader.Loader {
+	if s.configLoader == nil {
+		s.configLoader = loader.New(s.toolsFilePaths,
+			loader.WithPluginOptions(s.pluginOpts...),
+			loader.WithSecrets(s.secrets, s.secretOpts...),
+			loader.WithRateLimiter(s.rateLimiter),
+		)
 	}
-
-	slog.Info("tools loaded", slog.Int("count", len(tools)))
-	return nil
+	return s.configLoader
 }
 
 func WithAuth(a *auth.Authenticator) ServerOption {
@@ -120,8 +139,13 @@ func WithAuth(a *auth.Authenticator) ServerOption {
 }
 
 func WithToolsFile(path string) ServerOption {
+	return WithToolsFiles(path)
+}
+
+// WithToolsFiles adds config files, directories or glob patterns to load tools from.
+func WithToolsFiles(paths ...string) ServerOption {
 	return func(s *Server) {
-		s.toolsFilePath = path
+		s.toolsFilePaths = append(s.toolsFilePaths, paths...)
 	}
 }
 
@@ -143,37 +167,33 @@ func WithPort(port string) ServerOption {
 	}
 }
 
-func WithHttpServer(server *http.Server) ServerOption {
+// WithPluginOptions sets the options used to load plugins.
+func WithPluginOptions(opts ...plugin.Option) ServerOption {
 	return func(s *Server) {
-		s.httpSrv = server
+		s.pluginOpts = append(s.pluginOpts, opts...)
 	}
 }
 
-func (s *Server) getHooks() *server.Hooks {
-	hooks := &server.Hooks{}
-
-	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
-		slog.Info("client connected", slog.String("sessionId", session.SessionID()))
-		monitoring.SessionStarts.Inc()
-		monitoring.ActiveSessions.Inc()
-	})
-
-	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
-		slog.Warn("client disconnected", slog.String("sessionId", session.SessionID()))
-		monitoring.SessionCloses.Inc()
-		monitoring.ActiveSessions.Dec()
-	})
-
-	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
-		slog.Info("processing request", slog.String("method", string(method)))
-	})
+// WithSecrets sets the store the secret providers of config files are registered with. It should be the
+// store of the executor.
+func WithSecrets(store *secret.Store, opts ...secret.HTTPOption) ServerOption {
+	return func(s *Server) {
+		s.secrets = store
+		s.secretOpts = append(s.secretOpts, opts...)
+	}
+}
 
-	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
-		slog.Error("error occurred", slog.String("method", string(method)), slog.String("error", err.Error()))
-		monitoring.ErrorsTotal.WithLabelValues(string(method)).Inc()
-	})
+// WithRateLimiter enforces the limiter on tool calls. Tools and APIs add their rate limits when loaded.
+func WithRateLimiter(l *ratelimit.Limiter) ServerOption {
+	return func(s *Server) {
+		s.rateLimiter = l
+	}
+}
 
-	return hooks
+func WithHttpServer(server *http.Server) ServerOption {
+	return func(s *Server) {
+		s.httpSrv = server
+	}
 }
 
 func (s *Server) runWithSSE() error {
@@ -223,41 +243,3 @@ func (s *Server) startWithGracefulShutdown(initFunc func(), shutdownFunc func(co
 
 	slog.Info("server stopped")
 }
-
-// resolveEnvPlaceholders resolves placeholders like {{env VAR_NAME:default}} with environment values.
-func (s *Server) resolveEnvPlaceholders(in string) string {
-	var b strings.Builder
-	b.Grow(len(in))
-
-	i := 0
-	for i < len(in) {
-		start := strings.Index(in[i:], "{{env ")
-		if start == -1 {
-			b.WriteString(in[i:])
-			break
-		}
-		start += i
-		b.WriteString(in[i:start])
-		end := strings.Index(in[start:], "}}")
-		if end == -1 {
-			b.WriteString(in[start:])
-			break
-		}
-		end += start
-
-		content := strings.TrimSpace(in[start+6 : end])
-		varName := strings.TrimSpace(content)
-		defaultValue := ""
-		if idx := strings.Index(content, ":"); idx != -1 {
-			varName = strings.TrimSpace(content[:idx])
-			defaultValue = strings.TrimSpace(content[idx+1:])
-		}
-		if val := os.Getenv(varName); val != "" {
-			b.WriteString(val)
-		} else {
-			b.WriteString(defaultValue)
-		}
-		i = end + 2
-	}
-	return b.String()
-}
@@ -1,17 +1,24 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"testing"
+	"time"
 
 	"github.com/AdamShannag/api-mcp-server/internal/auth"
+	"github.com/AdamShannag/api-mcp-server/internal/config"
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
 	"github.com/AdamShannag/api-mcp-server/pkg/tool"
 	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewServer_WithDefaults(t *testing.T) {
@@ -21,7 +28,7 @@ func TestNewServer_WithDefaults(t *testing.T) {
 	assert.Equal(t, defaultSseHost, s.host)
 	assert.Equal(t, strconv.Itoa(defaultSsePort), s.port)
 	assert.Nil(t, s.auth)
-	assert.Empty(t, s.toolsFilePath)
+	assert.Empty(t, s.toolsFilePaths)
 }
 
 func TestNewServer_WithOptions(t *testing.T) {
@@ -37,7 +44,7 @@ func TestNewServer_WithOptions(t *testing.T) {
 	assert.Equal(t, "sse", s.transport)
 	assert.Equal(t, "0.0.0.0", s.host)
 	assert.Equal(t, "9999", s.port)
-	assert.Equal(t, "/tmp/tools.json", s.toolsFilePath)
+	assert.Equal(t, []string{"/tmp/tools.json"}, s.toolsFilePaths)
 	assert.Equal(t, authenticator, s.auth)
 }
 
@@ -68,6 +75,177 @@ func TestServer_LoadTools_Success(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestServer_LoadTools_ConfigObject(t *testing.T) {
+	tmpDir := t.TempDir()
+	toolsFile := filepath.Join(tmpDir, "tools.json")
+
+	_ = os.WriteFile(toolsFile, []byte(`{"tools": [{"name": "Ping", "request": {"host": "example.com", "endpoint": "/ping"}}]}`), 0644)
+
+	s := NewServer("stdio", WithToolsFile(toolsFile))
+	assert.NoError(t, s.LoadTools(&tool.Manager{}))
+
+	_ = os.WriteFile(toolsFile, []byte(`{"plugins": [{"name": "ulid", "path": "ulid.wasm"}], "tools": []}`), 0644)
+
+	err := s.LoadTools(&tool.Manager{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "plugin ulid: failed to read module")
+	assert.Contains(t, err.Error(), filepath.Join(tmpDir, "ulid.wasm"))
+}
+
+func TestServer_LoadTools_MultipleFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, "tools"), 0755)
+
+	_ = os.WriteFile(filepath.Join(tmpDir, "tools", "gitlab.json"), []byte(`{"prefix": "gitlab_", "tools": [{"name": "GetProject", "request": {"host": "gitlab.com"}}]}`), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "tools", "jira.json"), []byte(`{"prefix": "jira_", "tools": [{"name": "GetProject", "request": {"host": "jira.com"}}]}`), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "extra.json"), []byte(`[{"name": "Ping", "request": {"host": "example.com"}}]`), 0644)
+
+	s := NewServer("stdio", WithToolsFiles(filepath.Join(tmpDir, "tools"), filepath.Join(tmpDir, "*.json")))
+	assert.NoError(t, s.LoadTools(&tool.Manager{}))
+
+	assert.ElementsMatch(t, []string{"gitlab_GetProject", "jira_GetProject", "Ping"}, toolNames(t, s))
+}
+
+func TestServer_LoadTools_YAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	toolsFile := filepath.Join(tmpDir, "tools.yaml")
+	t.Setenv("PING_HOST", "ping.example.com")
+
+	_ = os.WriteFile(toolsFile, []byte("- name: Ping\n  request:\n    host: \"{{env PING_HOST}}\"\n"), 0644)
+
+	s := NewServer("stdio", WithToolsFile(toolsFile))
+	assert.NoError(t, s.LoadTools(&tool.Manager{}))
+	assert.Equal(t, []string{"Ping"}, toolNames(t, s))
+
+	_ = os.WriteFile(toolsFile, []byte("- name: Ping\n  args: yes\n"), 0644)
+
+	err := s.LoadTools(&tool.Manager{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), toolsFile+":2:9")
+}
+
+func TestServer_LoadTools_DuplicateNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "a.json")
+	second := filepath.Join(tmpDir, "b.json")
+
+	_ = os.WriteFile(first, []byte(`[{"name": "Ping", "request": {"host": "a.com"}}]`), 0644)
+	_ = os.WriteFile(second, []byte(`[{"name": "Ping", "request": {"host": "b.com"}}]`), 0644)
+
+	s := NewServer("stdio", WithToolsFiles(tmpDir))
+
+	err := s.LoadTools(&tool.Manager{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate tool "Ping"`)
+	assert.Contains(t, err.Error(), first)
+	assert.Contains(t, err.Error(), second)
+}
+
+func TestServer_LoadTools_InvalidConfig(t *testing.T) {
+	toolsFile := filepath.Join(t.TempDir(), "tools.json")
+	_ = os.WriteFile(toolsFile, []byte(`[
+  {"name": "Ping", "request": {"host": "example.com", "endpoint": "/ping"}},
+  {"name": "GetTodo", "request": {"host": "example.com", "endpoint": "/todos/:id"}}
+]`), 0644)
+
+	s := NewServer("stdio", WithToolsFile(toolsFile))
+
+	err := s.LoadTools(tool.NewManager(nil))
+	var problems config.Problems
+	require.ErrorAs(t, err, &problems)
+	assert.Equal(t, toolsFile+`:3:70: tools[1].request.endpoint: endpoint param ":id" is missing from pathParams`, problems[0].String())
+	assert.Empty(t, toolNames(t, s))
+
+	assert.ErrorAs(t, s.ValidateTools(), &problems)
+}
+
+func TestServer_LoadTools_Secrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	toolsFile := filepath.Join(tmpDir, "tools.json")
+	_ = os.MkdirAll(filepath.Join(tmpDir, "secrets"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "secrets", "token"), []byte("s3cret"), 0600)
+	_ = os.WriteFile(toolsFile, []byte(`{
+  "secrets": {"local": {"type": "docker", "path": "secrets"}},
+  "tools": [{"name": "Ping", "request": {"host": "example.com", "headers": {"Authorization": "{{secret local token}}"}}}]
+}`), 0644)
+
+	err := NewServer("stdio", WithToolsFile(toolsFile)).LoadTools(tool.NewManager(nil))
+	assert.ErrorContains(t, err, "require a secret store")
+
+	store := secret.NewStore()
+	s := NewServer("stdio", WithToolsFile(toolsFile), WithSecrets(store))
+	require.NoError(t, s.LoadTools(tool.NewManager(nil)))
+
+	val, err := store.Get(context.Background(), "local", "token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", val)
+}
+
+func TestServer_LoadTools_RateLimits(t *testing.T) {
+	toolsFile := filepath.Join(t.TempDir(), "tools.json")
+	_ = os.WriteFile(toolsFile, []byte(`{
+  "apis": {"example": {"baseURL": "https://example.com", "rateLimit": "2/m"}},
+  "tools": [
+    {"name": "Ping", "rateLimit": "1/m", "request": {"api": "example", "endpoint": "/ping"}},
+    {"name": "Pong", "request": {"api": "example", "endpoint": "/pong"}}
+  ]
+}`), 0644)
+
+	// A fixed clock keeps the retry delay independent of the time between calls.
+	now := time.Unix(0, 0)
+	limiter := ratelimit.New(ratelimit.WithClock(func() time.Time { return now }))
+	s := NewServer("stdio", WithToolsFile(toolsFile), WithRateLimiter(limiter))
+	require.NoError(t, s.LoadTools(tool.NewManager(request.NewExecutor(request.WithDryRun()))))
+
+	call := func(name string) mcp.CallToolResult {
+		msg := s.server.HandleMessage(context.Background(),
+			[]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`"}}`))
+		resp, ok := msg.(mcp.JSONRPCResponse)
+		require.True(t, ok)
+		return resp.Result.(mcp.CallToolResult)
+	}
+
+	assert.False(t, call("Ping").IsError)
+	result := call("Ping")
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "rate limit per tool exceeded, retry after 1m0s")
+
+	assert.False(t, call("Pong").IsError)
+	result = call("Pong")
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "rate limit per host exceeded")
+}
+
+func TestServer_LoadTools_BatchRateLimits(t *testing.T) {
+	toolsFile := filepath.Join(t.TempDir(), "tools.json")
+	_ = os.WriteFile(toolsFile, []byte(`{
+  "tools": [
+    {"name": "Ping", "rateLimit": "3/m", "batch": {}, "request": {"host": "example.com", "endpoint": "/ping"}}
+  ]
+}`), 0644)
+
+	s := NewServer("stdio", WithToolsFile(toolsFile), WithRateLimiter(ratelimit.New()))
+	require.NoError(t, s.LoadTools(tool.NewManager(request.NewExecutor(request.WithDryRun()))))
+
+	call := func(name, args string) mcp.CallToolResult {
+		msg := s.server.HandleMessage(context.Background(),
+			[]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`))
+		resp, ok := msg.(mcp.JSONRPCResponse)
+		require.True(t, ok)
+		return resp.Result.(mcp.CallToolResult)
+	}
+
+	result := call("PingBatch", `{"items":[{},{},{},{}]}`)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "4 items are more than it allows at once")
+
+	assert.False(t, call("PingBatch", `{"items":[{},{}]}`).IsError)
+	assert.False(t, call("Ping", `{}`).IsError)
+	result = call("Ping", `{}`)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "rate limit per tool exceeded")
+}
+
 func TestServer_LoadTools_InvalidJSON(t *testing.T) {
 	tmpDir := t.TempDir()
 	badJSON := filepath.Join(tmpDir, "bad.json")
@@ -91,94 +269,18 @@ func TestServer_LoadTools_FileMissing(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to read file")
 }
 
-func TestResolveEnvPlaceholders(t *testing.T) {
-	s := &Server{}
-
-	t.Setenv("HOST_URL", "https://example.com")
-	t.Setenv("API_KEY", "secret_key")
-
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "env var present, no default",
-			input:    "Connect to {{env HOST_URL}} now",
-			expected: "Connect to https://example.com now",
-		},
-		{
-			name:     "env var missing, default used",
-			input:    "API key is {{env MISSING_KEY:default_key}}",
-			expected: "API key is default_key",
-		},
-		{
-			name:     "env var missing, no default",
-			input:    "Value: {{env MISSING}}",
-			expected: "Value: ",
-		},
-		{
-			name:     "multiple placeholders",
-			input:    "Host: {{env HOST_URL}}, Key: {{env API_KEY:default_key}}",
-			expected: "Host: https://example.com, Key: secret_key",
-		},
-		{
-			name:     "no placeholders",
-			input:    "Just a regular string",
-			expected: "Just a regular string",
-		},
-		{
-			name:     "malformed placeholder no closing braces",
-			input:    "Value {{env HOST_URL",
-			expected: "Value {{env HOST_URL",
-		},
-		{
-			name:     "placeholder with spaces and default",
-			input:    "URL: {{env   HOST_URL  :   https://default.com  }}",
-			expected: "URL: https://example.com",
-		},
-		{
-			name:     "placeholder with empty default",
-			input:    "Empty default {{env MISSING:}} end",
-			expected: "Empty default  end",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			out := s.resolveEnvPlaceholders(tt.input)
-			assert.Equal(t, tt.expected, out)
-		})
-	}
-}
-
-func BenchmarkResolveEnvPlaceholders_Manual(b *testing.B) {
-	s := &Server{}
-	b.Setenv("API_KEY", "live_key_123")
-	tmpl := largeTemplate()
+func toolNames(t *testing.T, s *Server) []string {
+	t.Helper()
 
-	b.ReportAllocs()
-	b.ResetTimer()
-
-	for n := 0; n < b.N; n++ {
-		_ = s.resolveEnvPlaceholders(tmpl)
+	msg := s.server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	resp, ok := msg.(mcp.JSONRPCResponse)
+	if !assert.True(t, ok) {
+		return nil
 	}
-}
 
-func largeTemplate() string {
-	var sb strings.Builder
-	sb.Grow(1_000_000)
-
-	for i := 0; i < 1000; i++ {
-		sb.WriteString(`{
-			"name": "Tool ` + string(rune('A'+(i%26))) + `",
-			"request": {
-				"host": "{{env API_KEY:default_key}}",
-				"headers": {
-					"Authorization": "Bearer {{env API_KEY}}"
-				}
-			}
-		},`)
+	var names []string
+	for _, tl := range resp.Result.(mcp.ListToolsResult).Tools {
+		names = append(names, tl.Name)
 	}
-	return sb.String()
+	return names
 }
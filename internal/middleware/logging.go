@@ -2,32 +2,38 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"github.com/AdamShannag/api-mcp-server/internal/monitoring"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"log/slog"
-	"strings"
+	"maps"
+	"slices"
 	"time"
 )
 
 func LoggingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		start := time.Now()
-		sessionID := server.ClientSessionFromContext(ctx).SessionID()
+		var sessionID string
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			sessionID = session.SessionID()
+		}
 		toolName := req.Params.Name
+		ctx = redact.WithTool(ctx, toolName)
 
 		monitoring.ToolInvocations.WithLabelValues(toolName).Inc()
 
-		argPairs := make([]string, 0, len(req.GetArguments()))
-		for k, v := range req.GetArguments() {
-			argPairs = append(argPairs, fmt.Sprintf("%s=%s", k, v))
+		arguments := req.GetArguments()
+		args := make([]any, 0, len(arguments))
+		for _, k := range slices.Sorted(maps.Keys(arguments)) {
+			args = append(args, slog.Any(k, arguments[k]))
 		}
 
-		slog.Info("tool call started",
+		slog.InfoContext(ctx, "tool call started",
 			slog.String("tool", toolName),
 			slog.String("sessionId", sessionID),
-			slog.String("args", strings.Join(argPairs, ", ")),
+			slog.Group("args", args...),
 		)
 
 		result, err := next(ctx, req)
@@ -36,7 +42,7 @@ func LoggingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
 		monitoring.ToolLatency.WithLabelValues(toolName).Observe(duration.Seconds())
 
 		if err != nil {
-			slog.Error("tool call failed",
+			slog.ErrorContext(ctx, "tool call failed",
 				slog.String("tool", toolName),
 				slog.String("sessionId", sessionID),
 				slog.Duration("duration", duration),
@@ -45,7 +51,7 @@ func LoggingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
 			return result, err
 		}
 
-		slog.Info("tool call completed",
+		slog.InfoContext(ctx, "tool call completed",
 			slog.String("tool", toolName),
 			slog.String("sessionId", sessionID),
 			slog.Duration("duration", duration),
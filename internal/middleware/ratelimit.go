@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/internal/auth"
+	"github.com/AdamShannag/api-mcp-server/internal/monitoring"
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// RateLimitMiddleware rejects tool calls exceeding the limits of their tool, upstream hosts, session or
+// API key with a tool error telling when to retry. Calls of batch variants count once per item.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			toolName := req.Params.Name
+
+			sessionID := ""
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				sessionID = session.SessionID()
+			}
+
+			calls := 1
+			if _, ok := limiter.BatchTool(toolName); ok {
+				items, _ := req.GetArguments()["items"].([]any)
+				calls = max(len(items), 1)
+			}
+
+			allowed, scope, retryAfter := limiter.AllowN(toolName, sessionID, auth.KeyFromContext(ctx), calls)
+			if allowed {
+				return next(ctx, req)
+			}
+
+			monitoring.RateLimited.WithLabelValues(toolName, scope).Inc()
+			if retryAfter == time.Duration(math.MaxInt64) {
+				slog.Warn("tool call rate limited",
+					slog.String("tool", toolName),
+					slog.String("sessionId", sessionID),
+					slog.String("scope", scope),
+					slog.Int("calls", calls),
+				)
+				return mcp.NewToolResultError(fmt.Sprintf("rate limit per %s exceeded: %d items are more than it allows at once, split the batch", scope, calls)), nil
+			}
+			retryAfter = max(retryAfter.Round(time.Millisecond), time.Millisecond)
+
+			slog.Warn("tool call rate limited",
+				slog.String("tool", toolName),
+				slog.String("sessionId", sessionID),
+				slog.String("scope", scope),
+				slog.Duration("retryAfter", retryAfter),
+			)
+
+			return mcp.NewToolResultError(fmt.Sprintf("rate limit per %s exceeded, retry after %s", scope, retryAfter)), nil
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package monitoring
 
 import (
+	"context"
 	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
 	"net/http"
 )
 
@@ -53,6 +57,61 @@ var (
 		},
 	)
 
+	RateLimited = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_total",
+			Help:      "Total number of tool calls rejected by a rate limit per scope",
+		},
+		[]string{"tool", "scope"},
+	)
+
+	CircuitState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_state",
+			Help:      "Circuit breaker state per upstream host (0 closed, 1 half-open, 2 open)",
+		},
+		[]string{"host"},
+	)
+
+	CacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Total number of response cache lookups per upstream host and result (hit, miss or revalidated)",
+		},
+		[]string{"host", "result"},
+	)
+
+	CoalescedCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "coalesced_calls_total",
+			Help:      "Total number of upstream requests served by an identical concurrent request per host",
+		},
+		[]string{"host"},
+	)
+
+	QueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queued_requests",
+			Help:      "Number of upstream requests waiting for an in-flight slot per host",
+		},
+		[]string{"host"},
+	)
+
+	QueueWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "queue_wait_seconds",
+			Help:      "Time upstream requests waited for an in-flight slot per host in seconds",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		},
+		[]string{"host"},
+	)
+
 	ErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: namespace,
@@ -69,7 +128,7 @@ func NewHttpServer(enabled bool, port string) *http.Server {
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.HandlerFor(newRegistry(), promhttp.HandlerOpts{}))
+	mux.Handle("/metrics", Handler())
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
@@ -81,6 +140,37 @@ func NewHttpServer(enabled bool, port string) *http.Server {
 	}
 }
 
+// Handler returns an http.Handler serving the metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(newRegistry(), promhttp.HandlerOpts{})
+}
+
+// AddHooks adds hooks logging and counting sessions and errors, and returns the hooks.
+func AddHooks(hooks *server.Hooks) *server.Hooks {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		slog.Info("client connected", slog.String("sessionId", session.SessionID()))
+		SessionStarts.Inc()
+		ActiveSessions.Inc()
+	})
+
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		slog.Warn("client disconnected", slog.String("sessionId", session.SessionID()))
+		SessionCloses.Inc()
+		ActiveSessions.Dec()
+	})
+
+	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
+		slog.Info("processing request", slog.String("method", string(method)))
+	})
+
+	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+		slog.Error("error occurred", slog.String("method", string(method)), slog.String("error", err.Error()))
+		ErrorsTotal.WithLabelValues(string(method)).Inc()
+	})
+
+	return hooks
+}
+
 func newRegistry() *prometheus.Registry {
 	reg := prometheus.NewRegistry()
 
@@ -90,6 +180,12 @@ func newRegistry() *prometheus.Registry {
 		SessionStarts,
 		SessionCloses,
 		ActiveSessions,
+		RateLimited,
+		CircuitState,
+		CacheLookups,
+		CoalescedCalls,
+		QueueDepth,
+		QueueWait,
 		ErrorsTotal,
 	)
 
@@ -0,0 +1,245 @@
+package ratelimit
+
+import (
+	"fmt"
+	"golang.org/x/time/rate"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes a limit applies to.
+const (
+	ScopeTool    = "tool"
+	ScopeHost    = "host"
+	ScopeSession = "session"
+	ScopeKey     = "key"
+)
+
+// idleTimeout is how often idle buckets are swept and how long they are kept at least. A bucket is only
+// dropped once idle for longer than it takes to refill, when it is full again anyway.
+const idleTimeout = 10 * time.Minute
+
+// Limit is a token bucket refilled at Rate tokens per second and holding up to Burst tokens.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// Parse parses a limit of the form count/period, such as 10/s, 100/m, 1000/h or 5/10s. The bucket holds
+// count tokens, so up to count calls may be made at once.
+func Parse(s string) (Limit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected count/period, e.g. 10/s", s)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: count must be a positive integer", s)
+	}
+
+	var d time.Duration
+	switch period {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	default:
+		d, err = time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: period must be s, m, h or a positive duration", s)
+		}
+	}
+
+	return Limit{Rate: rate.Limit(float64(n) / d.Seconds()), Burst: n}, nil
+}
+
+type Option func(*Limiter)
+
+// Limiter enforces token bucket limits per tool, upstream host, session and API key. A call is allowed
+// when every applicable bucket has a token; otherwise no token is taken.
+type Limiter struct {
+	defaults map[string]*Limit
+
+	mu        sync.Mutex
+	tools     map[string]toolInfo
+	batches   map[string]string
+	hosts     map[string]Limit
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type toolInfo struct {
+	limit *Limit
+	hosts []string
+}
+
+type bucketKey struct {
+	scope string
+	name  string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	refill   time.Duration
+	lastUsed time.Time
+}
+
+func New(opts ...Option) *Limiter {
+	l := &Limiter{
+		defaults: make(map[string]*Limit),
+		tools:    make(map[string]toolInfo),
+		batches:  make(map[string]string),
+		hosts:    make(map[string]Limit),
+		buckets:  make(map[bucketKey]*bucket),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// WithDefault sets the limit of every tool, host, session or API key, depending on the scope.
+func WithDefault(scope string, limit Limit) Option {
+	return func(l *Limiter) {
+		l.defaults[scope] = &limit
+	}
+}
+
+// WithClock sets the clock used to refill buckets.
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// SetTool registers the upstream hosts of a tool and its own limit, which replaces the default tool limit
+// when not nil.
+func (l *Limiter) SetTool(name string, limit *Limit, hosts ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tools[name] = toolInfo{limit: limit, hosts: hosts}
+}
+
+// SetBatchTool registers the batch variant of a tool, whose calls take one token per item from the buckets
+// of the tool.
+func (l *Limiter) SetBatchTool(name, tool string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.batches[name] = tool
+}
+
+// BatchTool returns the tool a batch variant was registered for.
+func (l *Limiter) BatchTool(name string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tool, ok := l.batches[name]
+	return tool, ok
+}
+
+// SetHost sets the limit of an upstream host, replacing the default host limit.
+func (l *Limiter) SetHost(host string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hosts[host] = limit
+}
+
+// Allow takes a token from every bucket applying to a call of the tool. Empty session and key names skip
+// their scopes. When a bucket is empty, Allow returns false with its scope and how long until it has a
+// token again.
+func (l *Limiter) Allow(tool, session, key string) (bool, string, time.Duration) {
+	return l.AllowN(tool, session, key, 1)
+}
+
+// AllowN is like Allow for n calls at once, e.g. the items of a batch. A batch variant takes its tokens
+// from the buckets of its tool. When n exceeds the burst of a bucket, the delay is the maximum duration.
+func (l *Limiter) AllowN(tool, session, key string, n int) (bool, string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if base, ok := l.batches[tool]; ok {
+		tool = base
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	type check struct {
+		key   bucketKey
+		limit *Limit
+	}
+	info := l.tools[tool]
+	checks := []check{{bucketKey{ScopeTool, tool}, info.limit}}
+	if checks[0].limit == nil {
+		checks[0].limit = l.defaults[ScopeTool]
+	}
+	for _, host := range info.hosts {
+		limit := l.defaults[ScopeHost]
+		if hostLimit, ok := l.hosts[host]; ok {
+			limit = &hostLimit
+		}
+		checks = append(checks, check{bucketKey{ScopeHost, host}, limit})
+	}
+	if session != "" {
+		checks = append(checks, check{bucketKey{ScopeSession, session}, l.defaults[ScopeSession]})
+	}
+	if key != "" {
+		checks = append(checks, check{bucketKey{ScopeKey, key}, l.defaults[ScopeKey]})
+	}
+
+	reservations := make([]*rate.Reservation, 0, len(checks))
+	for _, c := range checks {
+		if c.limit == nil {
+			continue
+		}
+		b := l.bucket(c.key, *c.limit, now)
+		r := b.limiter.ReserveN(now, n)
+		if delay := r.DelayFrom(now); !r.OK() || delay > 0 {
+			r.CancelAt(now)
+			for _, prev := range reservations {
+				prev.CancelAt(now)
+			}
+			if !r.OK() {
+				delay = time.Duration(math.MaxInt64)
+			}
+			return false, c.key.scope, delay
+		}
+		reservations = append(reservations, r)
+	}
+	return true, "", 0
+}
+
+func (l *Limiter) bucket(key bucketKey, limit Limit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok || b.limiter.Limit() != limit.Rate || b.limiter.Burst() != limit.Burst {
+		b = &bucket{
+			limiter: rate.NewLimiter(limit.Rate, limit.Burst),
+			refill:  time.Duration(float64(limit.Burst) / float64(limit.Rate) * float64(time.Second)),
+		}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+	return b
+}
+
+// sweep drops the buckets that are full again, at most once per idle timeout.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if idle := now.Sub(b.lastUsed); idle > idleTimeout && idle > b.refill {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package ratelimit_test
+
+import (
+	"github.com/AdamShannag/api-mcp-server/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want ratelimit.Limit
+		err  string
+	}{
+		{spec: "10/s", want: ratelimit.Limit{Rate: 10, Burst: 10}},
+		{spec: "120/m", want: ratelimit.Limit{Rate: 2, Burst: 120}},
+		{spec: "3600/h", want: ratelimit.Limit{Rate: 1, Burst: 3600}},
+		{spec: "5/10s", want: ratelimit.Limit{Rate: rate.Limit(0.5), Burst: 5}},
+		{spec: "10", err: "expected count/period"},
+		{spec: "-1/s", err: "count must be a positive integer"},
+		{spec: "1/day", err: "period must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			limit, err := ratelimit.Parse(tt.spec)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, limit)
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := ratelimit.New(
+		ratelimit.WithDefault(ratelimit.ScopeSession, ratelimit.Limit{Rate: 1, Burst: 3}),
+		ratelimit.WithClock(func() time.Time { return now }),
+	)
+	l.SetTool("GetItem", &ratelimit.Limit{Rate: 1, Burst: 2})
+
+	allowed, _, _ := l.Allow("GetItem", "s1", "")
+	assert.True(t, allowed)
+	allowed, _, _ = l.Allow("GetItem", "s1", "")
+	assert.True(t, allowed)
+
+	allowed, scope, retryAfter := l.Allow("GetItem", "s1", "")
+	assert.False(t, allowed)
+	assert.Equal(t, ratelimit.ScopeTool, scope)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// The rejected call took no session token, so one is left for another tool.
+	allowed, _, _ = l.Allow("ListItems", "s1", "")
+	assert.True(t, allowed)
+	allowed, scope, _ = l.Allow("ListItems", "s1", "")
+	assert.False(t, allowed)
+	assert.Equal(t, ratelimit.ScopeSession, scope)
+
+	allowed, _, _ = l.Allow("ListItems", "s2", "")
+	assert.True(t, allowed)
+
+	now = now.Add(time.Second)
+	allowed, _, _ = l.Allow("GetItem", "s1", "")
+	assert.True(t, allowed)
+}
+
+func TestLimiter_AllowHostsAndKeys(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := ratelimit.New(
+		ratelimit.WithDefault(ratelimit.ScopeHost, ratelimit.Limit{Rate: 1, Burst: 5}),
+		ratelimit.WithDefault(ratelimit.ScopeKey, ratelimit.Limit{Rate: 1, Burst: 1}),
+		ratelimit.WithClock(func() time.Time { return now }),
+	)
+	l.SetHost("gitlab.com", ratelimit.Limit{Rate: 1, Burst: 1})
+	l.SetTool("GetProject", nil, "gitlab.com")
+	l.SetTool("GetPipeline", nil, "gitlab.com")
+
+	allowed, _, _ := l.Allow("GetProject", "", "")
+	assert.True(t, allowed)
+	allowed, scope, _ := l.Allow("GetPipeline", "", "")
+	assert.False(t, allowed)
+	assert.Equal(t, ratelimit.ScopeHost, scope)
+
+	allowed, _, _ = l.Allow("Other", "", "key-1")
+	assert.True(t, allowed)
+	allowed, scope, _ = l.Allow("Other", "", "key-1")
+	assert.False(t, allowed)
+	assert.Equal(t, ratelimit.ScopeKey, scope)
+	allowed, _, _ = l.Allow("Other", "", "key-2")
+	assert.True(t, allowed)
+}
+
+func TestLimiter_AllowNBatch(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := ratelimit.New(ratelimit.WithClock(func() time.Time { return now }))
+	l.SetHost("gitlab.com", ratelimit.Limit{Rate: 1, Burst: 10})
+	l.SetTool("GetProject", &ratelimit.Limit{Rate: 1, Burst: 3}, "gitlab.com")
+	l.SetBatchTool("GetProjectBatch", "GetProject")
+
+	base, ok := l.BatchTool("GetProjectBatch")
+	assert.True(t, ok)
+	assert.Equal(t, "GetProject", base)
+
+	allowed, _, _ := l.AllowN("GetProjectBatch", "", "", 2)
+	assert.True(t, allowed)
+	allowed, _, _ = l.Allow("GetProject", "", "")
+	assert.True(t, allowed)
+	allowed, scope, _ := l.Allow("GetProject", "", "")
+	assert.False(t, allowed)
+	assert.Equal(t, ratelimit.ScopeTool, scope)
+
+	allowed, scope, retryAfter := l.AllowN("GetProjectBatch", "", "", 4)
+	assert.False(t, allowed)
+	assert.Equal(t, ratelimit.ScopeTool, scope)
+	assert.Equal(t, time.Duration(math.MaxInt64), retryAfter)
+}
+
+func TestLimiter_SweepKeepsRefillingBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := ratelimit.New(ratelimit.WithClock(func() time.Time { return now }))
+	l.SetTool("Export", &ratelimit.Limit{Rate: rate.Every(time.Hour), Burst: 1})
+
+	allowed, _, _ := l.Allow("Export", "", "")
+	assert.True(t, allowed)
+
+	now = now.Add(30 * time.Minute)
+	allowed, _, retryAfter := l.Allow("Export", "", "")
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Minute, retryAfter)
+
+	now = now.Add(time.Hour)
+	allowed, _, _ = l.Allow("Export", "", "")
+	assert.True(t, allowed)
+}
@@ -19,3 +19,15 @@ func GetLogLevel(lv string) slog.Level {
 		return slog.LevelInfo
 	}
 }
+
+// StringSlice is a flag.Value collecting every occurrence of a repeatable flag.
+type StringSlice []string
+
+func (s *StringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *StringSlice) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
@@ -32,3 +32,13 @@ func TestGetLogLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestStringSlice(t *testing.T) {
+	var s util.StringSlice
+
+	assert.NoError(t, s.Set("/tmp/a"))
+	assert.NoError(t, s.Set("/tmp/b"))
+
+	assert.Equal(t, util.StringSlice{"/tmp/a", "/tmp/b"}, s)
+	assert.Equal(t, "/tmp/a,/tmp/b", s.String())
+}
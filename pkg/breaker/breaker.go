@@ -0,0 +1,263 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// State is the state of the circuit of a host.
+type State int
+
+const (
+	// Closed lets requests through, counting their failures.
+	Closed State = iota
+	// HalfOpen lets a limited number of probe requests through to find out whether the host recovered.
+	HalfOpen
+	// Open fails requests without sending them.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Outcome is the result of a request let through by the breaker.
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	// Ignored releases the request without counting it, e.g. when it was canceled before the host answered.
+	Ignored
+)
+
+// ErrOpen is returned for requests to a host whose circuit is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+const (
+	DefaultFailureRatio     = 0.5
+	DefaultMinRequests      = 10
+	DefaultWindow           = time.Minute
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+type Option func(*Breaker)
+
+// Observer is called on every state change of a circuit, with the breaker locked.
+type Observer func(host string, from, to State)
+
+// Breaker keeps a circuit per upstream host. A circuit opens when, within a window, at least minRequests
+// requests were made and the ratio of failures reached failureRatio. After openTimeout it turns half-open
+// and lets halfOpenRequests probes through: it closes when they all succeed and opens again when one fails.
+type Breaker struct {
+	failureRatio     float64
+	minRequests      int
+	window           time.Duration
+	openTimeout      time.Duration
+	halfOpenRequests int
+	observer         Observer
+	now              func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+func New(opts ...Option) *Breaker {
+	b := &Breaker{
+		failureRatio:     DefaultFailureRatio,
+		minRequests:      DefaultMinRequests,
+		window:           DefaultWindow,
+		openTimeout:      DefaultOpenTimeout,
+		halfOpenRequests: DefaultHalfOpenRequests,
+		now:              time.Now,
+		circuits:         make(map[string]*circuit),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// WithFailureRatio sets the ratio of failed requests, between 0 and 1, opening a circuit.
+func WithFailureRatio(ratio float64) Option {
+	return func(b *Breaker) {
+		b.failureRatio = ratio
+	}
+}
+
+// WithMinRequests sets how many requests a window needs before its failure ratio is considered.
+func WithMinRequests(n int) Option {
+	return func(b *Breaker) {
+		b.minRequests = max(n, 1)
+	}
+}
+
+// WithWindow sets the period over which failures are counted.
+func WithWindow(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.window = d
+	}
+}
+
+// WithOpenTimeout sets how long a circuit stays open before probing the host.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.openTimeout = d
+	}
+}
+
+// WithHalfOpenRequests sets how many probes must succeed to close a half-open circuit.
+func WithHalfOpenRequests(n int) Option {
+	return func(b *Breaker) {
+		b.halfOpenRequests = max(n, 1)
+	}
+}
+
+// WithObserver sets a function called on every state change, e.g. to export the states as metrics.
+func WithObserver(observer Observer) Option {
+	return func(b *Breaker) {
+		b.observer = observer
+	}
+}
+
+// WithClock sets the clock used for windows and timeouts.
+func WithClock(now func() time.Time) Option {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+// State returns the state of the circuit of host.
+func (b *Breaker) State(host string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[host]; ok {
+		return c.state
+	}
+	return Closed
+}
+
+// Allow returns an error wrapping ErrOpen when requests to host must fail fast. Otherwise the request may
+// be sent, and done must be called with its outcome.
+func (b *Breaker) Allow(host string) (done func(Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{windowStart: now}
+		b.circuits[host] = c
+	}
+
+	switch c.state {
+	case Open:
+		if wait := b.openTimeout - now.Sub(c.openedAt); wait > 0 {
+			return nil, fmt.Errorf("%w for %s after repeated failures, retry in %s", ErrOpen, host, wait.Round(time.Second))
+		}
+		c.probes, c.successes = 0, 0
+		b.transition(host, c, HalfOpen)
+		fallthrough
+	case HalfOpen:
+		if c.probes >= b.halfOpenRequests {
+			return nil, fmt.Errorf("%w for %s while probing whether it recovered", ErrOpen, host)
+		}
+		c.probes++
+	default:
+		if now.Sub(c.windowStart) >= b.window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+	}
+
+	state := c.state
+	return func(outcome Outcome) {
+		b.record(host, state, outcome)
+	}, nil
+}
+
+// record counts the outcome of a request allowed in the given state. An ignored probe frees its slot, so the
+// circuit stays half-open until another probe is answered.
+func (b *Breaker) record(host string, state State, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuits[host]
+	if c.state != state {
+		return
+	}
+
+	now := b.now()
+	switch state {
+	case HalfOpen:
+		if outcome == Ignored {
+			c.probes--
+			return
+		}
+		if outcome == Failure {
+			c.openedAt = now
+			b.transition(host, c, Open)
+			return
+		}
+		if c.successes++; c.successes >= b.halfOpenRequests {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+			b.transition(host, c, Closed)
+		}
+	case Closed:
+		if outcome == Ignored {
+			return
+		}
+		c.requests++
+		if outcome == Failure {
+			c.failures++
+		}
+		if c.failures > 0 && c.requests >= b.minRequests && float64(c.failures)/float64(c.requests) >= b.failureRatio {
+			c.openedAt = now
+			b.transition(host, c, Open)
+		}
+	}
+}
+
+func (b *Breaker) transition(host string, c *circuit, to State) {
+	from := c.state
+	c.state = to
+
+	log := slog.Info
+	if to == Open {
+		log = slog.Warn
+	}
+	log("circuit breaker state changed",
+		slog.String("host", host),
+		slog.String("from", from.String()),
+		slog.String("to", to.String()),
+	)
+
+	if b.observer != nil {
+		b.observer(host, from, to)
+	}
+}
@@ -0,0 +1,129 @@
+package breaker_test
+
+import (
+	"github.com/AdamShannag/api-mcp-server/pkg/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type transition struct {
+	host     string
+	from, to breaker.State
+}
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	now := time.Unix(0, 0)
+	var transitions []transition
+	b := breaker.New(
+		breaker.WithFailureRatio(0.5),
+		breaker.WithMinRequests(4),
+		breaker.WithOpenTimeout(10*time.Second),
+		breaker.WithClock(func() time.Time { return now }),
+		breaker.WithObserver(func(host string, from, to breaker.State) {
+			transitions = append(transitions, transition{host, from, to})
+		}),
+	)
+
+	for _, outcome := range []breaker.Outcome{breaker.Success, breaker.Failure, breaker.Success, breaker.Failure} {
+		done, err := b.Allow("gitlab.com")
+		require.NoError(t, err)
+		done(outcome)
+	}
+	assert.Equal(t, breaker.Open, b.State("gitlab.com"))
+	assert.Equal(t, breaker.Closed, b.State("github.com"))
+
+	now = now.Add(4 * time.Second)
+	_, err := b.Allow("gitlab.com")
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.ErrorContains(t, err, "for gitlab.com after repeated failures, retry in 6s")
+
+	now = now.Add(6 * time.Second)
+	probe, err := b.Allow("gitlab.com")
+	require.NoError(t, err)
+	assert.Equal(t, breaker.HalfOpen, b.State("gitlab.com"))
+
+	_, err = b.Allow("gitlab.com")
+	assert.ErrorContains(t, err, "while probing whether it recovered")
+
+	probe(breaker.Success)
+	assert.Equal(t, breaker.Closed, b.State("gitlab.com"))
+
+	assert.Equal(t, []transition{
+		{"gitlab.com", breaker.Closed, breaker.Open},
+		{"gitlab.com", breaker.Open, breaker.HalfOpen},
+		{"gitlab.com", breaker.HalfOpen, breaker.Closed},
+	}, transitions)
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := breaker.New(
+		breaker.WithMinRequests(1),
+		breaker.WithOpenTimeout(time.Second),
+		breaker.WithClock(func() time.Time { return now }),
+	)
+
+	done, _ := b.Allow("gitlab.com")
+	done(breaker.Failure)
+	assert.Equal(t, breaker.Open, b.State("gitlab.com"))
+
+	now = now.Add(time.Second)
+	probe, err := b.Allow("gitlab.com")
+	require.NoError(t, err)
+	probe(breaker.Failure)
+
+	assert.Equal(t, breaker.Open, b.State("gitlab.com"))
+	_, err = b.Allow("gitlab.com")
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+}
+
+func TestBreaker_WindowResetsCounts(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := breaker.New(
+		breaker.WithMinRequests(2),
+		breaker.WithWindow(time.Minute),
+		breaker.WithClock(func() time.Time { return now }),
+	)
+
+	done, _ := b.Allow("gitlab.com")
+	done(breaker.Failure)
+
+	now = now.Add(time.Minute)
+	done, _ = b.Allow("gitlab.com")
+	done(breaker.Failure)
+	assert.Equal(t, breaker.Closed, b.State("gitlab.com"))
+
+	done, _ = b.Allow("gitlab.com")
+	done(breaker.Success)
+	assert.Equal(t, breaker.Open, b.State("gitlab.com"))
+}
+
+func TestBreaker_IgnoredProbeStaysHalfOpen(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := breaker.New(
+		breaker.WithMinRequests(1),
+		breaker.WithOpenTimeout(time.Second),
+		breaker.WithClock(func() time.Time { return now }),
+	)
+
+	done, _ := b.Allow("gitlab.com")
+	done(breaker.Ignored)
+	assert.Equal(t, breaker.Closed, b.State("gitlab.com"))
+
+	done, _ = b.Allow("gitlab.com")
+	done(breaker.Failure)
+	assert.Equal(t, breaker.Open, b.State("gitlab.com"))
+
+	now = now.Add(time.Second)
+	probe, err := b.Allow("gitlab.com")
+	require.NoError(t, err)
+	probe(breaker.Ignored)
+	assert.Equal(t, breaker.HalfOpen, b.State("gitlab.com"))
+
+	probe, err = b.Allow("gitlab.com")
+	require.NoError(t, err)
+	probe(breaker.Success)
+	assert.Equal(t, breaker.Closed, b.State("gitlab.com"))
+}
@@ -0,0 +1,225 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrFull is returned for calls that found no free slot within the queue timeout.
+var ErrFull = errors.New("too many concurrent requests")
+
+// DefaultQueueTimeout is how long a call waits for a free slot before failing.
+const DefaultQueueTimeout = 10 * time.Second
+
+type Option func(*Bulkhead)
+
+// QueueObserver is called, with the bulkhead locked, whenever the number of calls queued for a host changes.
+type QueueObserver func(host string, depth int)
+
+// WaitObserver is called with how long a queued call waited, whether it got its slots or not.
+type WaitObserver func(host string, wait time.Duration)
+
+// Bulkhead bounds the calls in flight per upstream host and in total. Calls over a limit queue until a slot
+// frees up, and fail with ErrFull after the queue timeout.
+type Bulkhead struct {
+	perHost      int
+	queueTimeout time.Duration
+	onQueue      QueueObserver
+	onWait       WaitObserver
+	now          func() time.Time
+	global       chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+type host struct {
+	slots  chan struct{}
+	queued int
+}
+
+func New(opts ...Option) *Bulkhead {
+	b := &Bulkhead{
+		queueTimeout: DefaultQueueTimeout,
+		now:          time.Now,
+		hosts:        make(map[string]*host),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// WithMaxInFlight bounds the calls in flight across all hosts. Zero, the default, means no limit.
+func WithMaxInFlight(n int) Option {
+	return func(b *Bulkhead) {
+		b.global = nil
+		if n > 0 {
+			b.global = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithMaxInFlightPerHost bounds the calls in flight to each host. Zero, the default, means no limit.
+func WithMaxInFlightPerHost(n int) Option {
+	return func(b *Bulkhead) {
+		b.perHost = max(n, 0)
+	}
+}
+
+// WithQueueTimeout sets how long a call waits for a free slot. Zero or less fails calls over a limit at once.
+func WithQueueTimeout(d time.Duration) Option {
+	return func(b *Bulkhead) {
+		b.queueTimeout = d
+	}
+}
+
+// WithQueueObserver reports the queue depth per host, e.g. to export it as a metric.
+func WithQueueObserver(o QueueObserver) Option {
+	return func(b *Bulkhead) {
+		b.onQueue = o
+	}
+}
+
+// WithWaitObserver reports how long queued calls waited, e.g. to export it as a metric.
+func WithWaitObserver(o WaitObserver) Option {
+	return func(b *Bulkhead) {
+		b.onWait = o
+	}
+}
+
+// WithClock replaces time.Now to measure waits, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(b *Bulkhead) {
+		b.now = now
+	}
+}
+
+// Acquire takes a slot of the host and a global slot, queueing while none is free. The returned release
+// func frees them and may be called more than once.
+func (b *Bulkhead) Acquire(ctx context.Context, name string) (func(), error) {
+	h := b.host(name)
+
+	var (
+		release = func() {}
+		start   time.Time
+		timer   *time.Timer
+	)
+	for _, slots := range []chan struct{}{h.slots, b.global} {
+		if slots == nil {
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			if timer == nil {
+				start = b.now()
+				timer = time.NewTimer(max(b.queueTimeout, 0))
+				defer timer.Stop()
+				b.queue(name, h, 1)
+				defer b.queue(name, h, -1)
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				release()
+				b.wait(name, start)
+				return nil, ctx.Err()
+			case <-timer.C:
+				release()
+				b.wait(name, start)
+				return nil, fmt.Errorf("%w to %s, no slot freed up within %s", ErrFull, name, max(b.queueTimeout, 0))
+			}
+		}
+
+		prev := release
+		release = func() {
+			<-slots
+			prev()
+		}
+	}
+
+	if timer != nil {
+		b.wait(name, start)
+	}
+	return sync.OnceFunc(release), nil
+}
+
+// Transport returns a round tripper holding slots of the request host until the response body is closed.
+func (b *Bulkhead) Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{bulkhead: b, next: next}
+}
+
+func (b *Bulkhead) host(name string) *host {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h, ok := b.hosts[name]
+	if !ok {
+		h = &host{}
+		if b.perHost > 0 {
+			h.slots = make(chan struct{}, b.perHost)
+		}
+		b.hosts[name] = h
+	}
+	return h
+}
+
+func (b *Bulkhead) queue(name string, h *host, delta int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h.queued += delta
+	if b.onQueue != nil {
+		b.onQueue(name, h.queued)
+	}
+}
+
+func (b *Bulkhead) wait(name string, start time.Time) {
+	if b.onWait != nil {
+		b.onWait(name, b.now().Sub(start))
+	}
+}
+
+type transport struct {
+	bulkhead *Bulkhead
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.bulkhead.Acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &body{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// body releases the slots of its request once closed.
+type body struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *body) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,125 @@
+package bulkhead_test
+
+import (
+	"context"
+	"github.com/AdamShannag/api-mcp-server/pkg/bulkhead"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBulkhead_PerHost(t *testing.T) {
+	b := bulkhead.New(bulkhead.WithMaxInFlightPerHost(2), bulkhead.WithQueueTimeout(10*time.Millisecond))
+
+	first, err := b.Acquire(context.Background(), "a.com")
+	require.NoError(t, err)
+	_, err = b.Acquire(context.Background(), "a.com")
+	require.NoError(t, err)
+
+	_, err = b.Acquire(context.Background(), "a.com")
+	assert.ErrorIs(t, err, bulkhead.ErrFull)
+	assert.ErrorContains(t, err, "too many concurrent requests to a.com, no slot freed up within 10ms")
+
+	_, err = b.Acquire(context.Background(), "b.com")
+	require.NoError(t, err)
+
+	first()
+	first()
+	_, err = b.Acquire(context.Background(), "a.com")
+	require.NoError(t, err)
+	_, err = b.Acquire(context.Background(), "a.com")
+	assert.ErrorIs(t, err, bulkhead.ErrFull)
+}
+
+func TestBulkhead_Global(t *testing.T) {
+	b := bulkhead.New(bulkhead.WithMaxInFlight(1), bulkhead.WithMaxInFlightPerHost(1), bulkhead.WithQueueTimeout(0))
+
+	release, err := b.Acquire(context.Background(), "a.com")
+	require.NoError(t, err)
+	_, err = b.Acquire(context.Background(), "b.com")
+	assert.ErrorIs(t, err, bulkhead.ErrFull)
+
+	// The host slot taken while waiting for the global one is given back.
+	release()
+	_, err = b.Acquire(context.Background(), "b.com")
+	require.NoError(t, err)
+	_, err = b.Acquire(context.Background(), "a.com")
+	assert.ErrorIs(t, err, bulkhead.ErrFull)
+}
+
+func TestBulkhead_Queue(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		depths []int
+		waits  int
+	)
+	b := bulkhead.New(
+		bulkhead.WithMaxInFlight(1),
+		bulkhead.WithQueueObserver(func(host string, depth int) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, "a.com", host)
+			depths = append(depths, depth)
+		}),
+		bulkhead.WithWaitObserver(func(_ string, wait time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Positive(t, wait)
+			waits++
+		}),
+	)
+
+	release, err := b.Acquire(context.Background(), "a.com")
+	require.NoError(t, err)
+
+	acquired := make(chan error)
+	go func() {
+		_, err := b.Acquire(context.Background(), "a.com")
+		acquired <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	release()
+	require.NoError(t, <-acquired)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = b.Acquire(ctx, "a.com")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{1, 0, 1, 0}, depths)
+	assert.Equal(t, 2, waits)
+}
+
+func TestBulkhead_Transport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	b := bulkhead.New(bulkhead.WithMaxInFlightPerHost(1), bulkhead.WithQueueTimeout(10*time.Millisecond))
+	client := &http.Client{Transport: b.Transport(http.DefaultTransport)}
+
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+
+	// The slot is held until the body is closed.
+	_, err = client.Get(ts.URL)
+	assert.ErrorIs(t, err, bulkhead.ErrFull)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	require.NoError(t, resp.Body.Close())
+
+	resp, err = client.Get(ts.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+}
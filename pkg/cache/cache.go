@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long responses are cached when a request sets no TTL.
+	DefaultTTL = time.Minute
+
+	// DefaultMaxEntrySize bounds the size of a cached response body.
+	DefaultMaxEntrySize = 1 << 20
+)
+
+// Results of a cache lookup, reported to the observer.
+const (
+	Hit         = "hit"
+	Miss        = "miss"
+	Revalidated = "revalidated"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Expires    time.Time   `json:"expires"`
+}
+
+func (e *Entry) clone() *Entry {
+	clone := *e
+	clone.Header = e.Header.Clone()
+	return &clone
+}
+
+func (e *Entry) size() int64 {
+	size := int64(len(e.Body))
+	for name, values := range e.Header {
+		size += int64(len(name))
+		for _, v := range values {
+			size += int64(len(v))
+		}
+	}
+	return size
+}
+
+// Backend stores cache entries by key. Get returns a copy the caller may modify. Implementations must be
+// safe for concurrent use.
+type Backend interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+}
+
+type Option func(*Cache)
+
+// Observer is called with the host and result of every cache lookup.
+type Observer func(host, result string)
+
+// Cache caches the responses of GET and HEAD requests made with a context carrying a TTL, see WithTTL.
+// Responses are keyed by method, URL and request headers. Upstream Cache-Control max-age shortens the
+// TTL, no-store prevents caching and no-cache forces revalidation. Stale entries with an ETag or
+// Last-Modified header are revalidated with a conditional request.
+type Cache struct {
+	backend      Backend
+	maxEntrySize int64
+	observer     Observer
+	now          func() time.Time
+}
+
+func New(backend Backend, opts ...Option) *Cache {
+	c := &Cache{
+		backend:      backend,
+		maxEntrySize: DefaultMaxEntrySize,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithMaxEntrySize sets the size of the largest response body cached.
+func WithMaxEntrySize(size int64) Option {
+	return func(c *Cache) {
+		c.maxEntrySize = size
+	}
+}
+
+// WithObserver sets a function called with the result of every lookup, e.g. to export hit and miss metrics.
+func WithObserver(observer Observer) Option {
+	return func(c *Cache) {
+		c.observer = observer
+	}
+}
+
+// WithClock sets the clock used for expiry.
+func WithClock(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+type ttlKey struct{}
+
+// WithTTL returns a context enabling the cache for the requests made with it, caching responses for ttl.
+func WithTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, ttlKey{}, ttl)
+}
+
+// Transport returns a round tripper serving cacheable requests from the cache, sending the others to next.
+func (c *Cache) Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{cache: c, next: next}
+}
+
+// Key returns the cache key of a request: a hash of its method, URL and headers, so responses to
+// requests with different credentials are kept apart.
+func Key(req *http.Request) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.String())
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		if name == "If-None-Match" || name == "If-Modified-Since" {
+			continue
+		}
+		_, _ = fmt.Fprintf(h, "%s: %s\n", name, strings.Join(req.Header[name], ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type transport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ttl, ok := req.Context().Value(ttlKey{}).(time.Duration)
+	if !ok || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return t.next.RoundTrip(req)
+	}
+
+	c := t.cache
+	key := Key(req)
+	entry, found := c.backend.Get(key)
+	if found && c.now().Before(entry.Expires) {
+		c.observe(req, Hit)
+		return entry.response(req), nil
+	}
+
+	out := req
+	if found {
+		out = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			out.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if found && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		for _, name := range []string{"Cache-Control", "ETag", "Expires", "Last-Modified"} {
+			if v := resp.Header.Get(name); v != "" {
+				entry.Header.Set(name, v)
+			}
+		}
+		if freshness, ok := freshness(entry.Header, ttl); ok {
+			entry.Expires = c.now().Add(freshness)
+			c.backend.Set(key, entry)
+		}
+		c.observe(req, Revalidated)
+		return entry.response(req), nil
+	}
+
+	c.observe(req, Miss)
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	fresh, ok := freshness(resp.Header, ttl)
+	if !ok || (fresh == 0 && resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxEntrySize+1))
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > c.maxEntrySize {
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.backend.Set(key, &Entry{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		Expires:    c.now().Add(fresh),
+	})
+	return resp, nil
+}
+
+func (c *Cache) observe(req *http.Request, result string) {
+	if c.observer != nil {
+		c.observer(req.URL.Host, result)
+	}
+}
+
+// freshness returns how long a response may be served from the cache: ttl, shortened by max-age, or 0
+// with no-cache. ok is false when the response must not be stored.
+func freshness(header http.Header, ttl time.Duration) (time.Duration, bool) {
+	fresh := ttl
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			fresh = 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				fresh = min(fresh, time.Duration(seconds)*time.Second)
+			}
+		}
+	}
+	return max(fresh, 0), true
+}
+
+func (e *Entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
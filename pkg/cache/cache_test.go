@@ -0,0 +1,187 @@
+package cache_test
+
+import (
+	"context"
+	"github.com/AdamShannag/api-mcp-server/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type harness struct {
+	t       *testing.T
+	url     string
+	now     time.Time
+	calls   int
+	client  *http.Client
+	results []string
+}
+
+func newHarness(t *testing.T, backend cache.Backend, handler func(w http.ResponseWriter, r *http.Request)) *harness {
+	h := &harness{t: t, now: time.Unix(0, 0)}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.calls++
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	c := cache.New(backend,
+		cache.WithClock(func() time.Time { return h.now }),
+		cache.WithObserver(func(_, result string) { h.results = append(h.results, result) }),
+	)
+	h.client = &http.Client{Transport: c.Transport(http.DefaultTransport)}
+	h.url = ts.URL
+	return h
+}
+
+func (h *harness) get(ctx context.Context, header ...string) (int, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url+"/items", nil)
+	require.NoError(h.t, err)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := h.client.Do(req)
+	require.NoError(h.t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func cached() context.Context {
+	return cache.WithTTL(context.Background(), time.Minute)
+}
+
+func TestCache_TTL(t *testing.T) {
+	h := newHarness(t, cache.NewMemory(0), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("items"))
+	})
+
+	for range 2 {
+		status, body := h.get(cached())
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "items", body)
+	}
+	assert.Equal(t, 1, h.calls)
+
+	h.get(cached(), "Authorization", "Bearer other")
+	assert.Equal(t, 2, h.calls)
+
+	h.get(context.Background())
+	assert.Equal(t, 3, h.calls)
+
+	h.now = h.now.Add(time.Minute)
+	h.get(cached())
+	assert.Equal(t, 4, h.calls)
+	assert.Equal(t, []string{cache.Miss, cache.Hit, cache.Miss, cache.Miss}, h.results)
+}
+
+func TestCache_CacheControl(t *testing.T) {
+	cacheControl := "max-age=10"
+	h := newHarness(t, cache.NewMemory(0), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", cacheControl)
+		_, _ = w.Write([]byte("items"))
+	})
+
+	h.get(cached())
+	h.now = h.now.Add(9 * time.Second)
+	h.get(cached())
+	assert.Equal(t, 1, h.calls)
+
+	h.now = h.now.Add(time.Second)
+	cacheControl = "no-store"
+	h.get(cached())
+	h.get(cached())
+	assert.Equal(t, 3, h.calls)
+}
+
+func TestCache_Revalidation(t *testing.T) {
+	version := 1
+	h := newHarness(t, cache.NewMemory(0), func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v` + strconv.Itoa(version) + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("items v" + strconv.Itoa(version)))
+	})
+
+	_, body := h.get(cached())
+	assert.Equal(t, "items v1", body)
+
+	status, body := h.get(cached())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "items v1", body)
+
+	version = 2
+	_, body = h.get(cached())
+	assert.Equal(t, "items v2", body)
+
+	assert.Equal(t, 3, h.calls)
+	assert.Equal(t, []string{cache.Miss, cache.Revalidated, cache.Miss}, h.results)
+}
+
+func TestCache_MaxEntrySize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+
+	backend := cache.NewMemory(0)
+	client := &http.Client{Transport: cache.New(backend, cache.WithMaxEntrySize(5)).Transport(http.DefaultTransport)}
+	req, _ := http.NewRequestWithContext(cached(), http.MethodGet, ts.URL, nil)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, "0123456789", string(body))
+	_, ok := backend.Get(cache.Key(req))
+	assert.False(t, ok)
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	m := cache.NewMemory(10)
+	m.Set("a", &cache.Entry{Body: []byte("aaaa")})
+	m.Set("b", &cache.Entry{Body: []byte("bbbb")})
+	_, _ = m.Get("a")
+	m.Set("c", &cache.Entry{Body: []byte("cccc")})
+
+	_, ok := m.Get("b")
+	assert.False(t, ok)
+	entry, ok := m.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "aaaa", string(entry.Body))
+	_, ok = m.Get("c")
+	assert.True(t, ok)
+}
+
+func TestDisk_PersistsAndEvicts(t *testing.T) {
+	dir := t.TempDir()
+	d, err := cache.NewDisk(dir, 1<<10)
+	require.NoError(t, err)
+
+	expires := time.Unix(100, 0).UTC()
+	d.Set("a", &cache.Entry{StatusCode: 200, Header: http.Header{"Etag": {`"1"`}}, Body: []byte("items"), Expires: expires})
+
+	d, err = cache.NewDisk(dir, 1<<10)
+	require.NoError(t, err)
+	entry, ok := d.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, &cache.Entry{StatusCode: 200, Header: http.Header{"Etag": {`"1"`}}, Body: []byte("items"), Expires: expires}, entry)
+
+	for i := range 20 {
+		d.Set(strconv.Itoa(i), &cache.Entry{Body: make([]byte, 100)})
+	}
+	_, ok = d.Get("a")
+	assert.False(t, ok)
+	_, ok = d.Get("19")
+	assert.True(t, ok)
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type disk struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// NewDisk returns a backend storing entries as files in dir, which survive restarts. When the files
+// exceed maxSize bytes, the least recently used are removed. A maxSize of 0 means DefaultMaxSize.
+func NewDisk(dir string, maxSize int64) (Backend, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	d := &disk{dir: dir, maxSize: maxSize}
+	files, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		d.size += f.size
+	}
+	return d, nil
+}
+
+func (d *disk) Get(key string) (*Entry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		slog.Warn("removing corrupt cache entry", slog.String("path", path))
+		d.removeFile(path)
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &entry, true
+}
+
+func (d *disk) Set(key string, entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil || int64(len(data)) > d.maxSize {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := d.path(key)
+	d.removeFile(path)
+
+	tmp, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		slog.Warn("failed to write cache entry", slog.String("error", err.Error()))
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		slog.Warn("failed to write cache entry", slog.String("error", err.Error()))
+		return
+	}
+	d.size += int64(len(data))
+
+	if d.size > d.maxSize {
+		d.evict()
+	}
+}
+
+// evict removes the least recently used files until the total size fits.
+func (d *disk) evict() {
+	files, err := d.files()
+	if err != nil {
+		slog.Warn("failed to evict cache entries", slog.String("error", err.Error()))
+		return
+	}
+	slices.SortFunc(files, func(a, b cacheFile) int { return a.used.Compare(b.used) })
+
+	for _, f := range files {
+		if d.size <= d.maxSize {
+			return
+		}
+		d.removeFile(f.path)
+	}
+}
+
+func (d *disk) removeFile(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if err = os.Remove(path); err == nil {
+		d.size -= info.Size()
+	}
+}
+
+func (d *disk) path(key string) string {
+	return filepath.Join(d.dir, key+".json")
+}
+
+type cacheFile struct {
+	path string
+	size int64
+	used time.Time
+}
+
+func (d *disk) files() ([]cacheFile, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache dir: %w", err)
+	}
+
+	files := make([]cacheFile, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{path: filepath.Join(d.dir, e.Name()), size: info.Size(), used: info.ModTime()})
+	}
+	return files, nil
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultMaxSize bounds the total size of the entries of a backend.
+const DefaultMaxSize = 64 << 20
+
+type memory struct {
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemory returns an in-memory backend holding up to maxSize bytes, evicting the least recently used
+// entries first. A maxSize of 0 means DefaultMaxSize.
+func NewMemory(maxSize int64) Backend {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &memory{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (m *memory) Get(key string) (*Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.lru.MoveToFront(elem)
+	return elem.Value.(*memoryItem).entry.clone(), true
+}
+
+func (m *memory) Set(key string, entry *Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+
+	entry = entry.clone()
+	if entry.size() > m.maxSize {
+		return
+	}
+	m.entries[key] = m.lru.PushFront(&memoryItem{key: key, entry: entry})
+	m.size += entry.size()
+
+	for m.size > m.maxSize {
+		m.remove(m.lru.Back())
+	}
+}
+
+func (m *memory) remove(elem *list.Element) {
+	item := m.lru.Remove(elem).(*memoryItem)
+	delete(m.entries, item.key)
+	m.size -= item.entry.size()
+}
@@ -0,0 +1,259 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"log/slog"
+	"strings"
+)
+
+type introspection struct {
+	Data *struct {
+		Schema *schema `json:"__schema"`
+	} `json:"data"`
+	Schema *schema `json:"__schema"`
+}
+
+type schema struct {
+	QueryType    *namedType `json:"queryType"`
+	MutationType *namedType `json:"mutationType"`
+	Types        []fullType `json:"types"`
+}
+
+type namedType struct {
+	Name string `json:"name"`
+}
+
+type fullType struct {
+	Kind          string      `json:"kind"`
+	Name          string      `json:"name"`
+	Fields        []field     `json:"fields"`
+	PossibleTypes []namedType `json:"possibleTypes"`
+}
+
+type field struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Args        []inputValue `json:"args"`
+	Type        typeRef      `json:"type"`
+}
+
+type inputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         typeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String renders the type reference in GraphQL notation, e.g. [ID!]!.
+func (t typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// check reports NON_NULL and LIST wrappers missing the type they wrap, which the other methods assume.
+func (t typeRef) check() error {
+	for ; t.Kind == "NON_NULL" || t.Kind == "LIST"; t = *t.OfType {
+		if t.OfType == nil {
+			return fmt.Errorf("%s type has no ofType", t.Kind)
+		}
+	}
+	return nil
+}
+
+// named unwraps NON_NULL and LIST wrappers down to the named type.
+func (t typeRef) named() typeRef {
+	if t.OfType != nil && (t.Kind == "NON_NULL" || t.Kind == "LIST") {
+		return t.OfType.named()
+	}
+	return t
+}
+
+// ToolsFromIntrospection generates one tool per query and mutation field of an introspection result.
+// The template provides the request target (host, endpoint, headers); its name is used as a prefix for
+// the generated tool names. Fields with required arguments that are not scalars or enums are skipped.
+func ToolsFromIntrospection(data []byte, template types.Tool) ([]types.Tool, error) {
+	var in introspection
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode introspection: %w", err)
+	}
+
+	s := in.Schema
+	if in.Data != nil && in.Data.Schema != nil {
+		s = in.Data.Schema
+	}
+	if s == nil {
+		return nil, errors.New("introspection has no __schema")
+	}
+
+	typesByName := make(map[string]fullType, len(s.Types))
+	for _, t := range s.Types {
+		for _, f := range t.Fields {
+			if err := f.Type.check(); err != nil {
+				return nil, fmt.Errorf("invalid introspection: field %s.%s: %w", t.Name, f.Name, err)
+			}
+			for _, a := range f.Args {
+				if err := a.Type.check(); err != nil {
+					return nil, fmt.Errorf("invalid introspection: arg %s of field %s.%s: %w", a.Name, t.Name, f.Name, err)
+				}
+			}
+		}
+		typesByName[t.Name] = t
+	}
+
+	var tools []types.Tool
+	for _, root := range []struct {
+		operation string
+		typ       *namedType
+	}{
+		{"query", s.QueryType},
+		{"mutation", s.MutationType},
+	} {
+		if root.typ == nil {
+			continue
+		}
+		for _, f := range typesByName[root.typ.Name].Fields {
+			t, ok := toTool(root.operation, f, typesByName, template)
+			if !ok {
+				slog.Warn("skipping graphql field with unsupported required arguments",
+					slog.String("operation", root.operation),
+					slog.String("field", f.Name),
+				)
+				continue
+			}
+			tools = append(tools, t)
+		}
+	}
+
+	return tools, nil
+}
+
+func toTool(operation string, f field, typesByName map[string]fullType, template types.Tool) (types.Tool, bool) {
+	var (
+		args      []types.Arg
+		variables []string
+		defs      []string
+		callArgs  []string
+	)
+
+	for _, a := range f.Args {
+		required := a.Type.Kind == "NON_NULL" && a.DefaultValue == nil
+		if !isScalarInput(a.Type) {
+			if required {
+				return types.Tool{}, false
+			}
+			continue
+		}
+
+		args = append(args, types.Arg{
+			Name:        a.Name,
+			Description: a.Description,
+			Required:    required,
+			Type:        argType(a.Type.named().Name),
+		})
+		variables = append(variables, a.Name)
+		defs = append(defs, fmt.Sprintf("$%s: %s", a.Name, a.Type))
+		callArgs = append(callArgs, fmt.Sprintf("%s: $%s", a.Name, a.Name))
+	}
+
+	var query strings.Builder
+	query.WriteString(operation)
+	if len(defs) > 0 {
+		query.WriteString("(" + strings.Join(defs, ", ") + ")")
+	}
+	query.WriteString(" { " + f.Name)
+	if len(callArgs) > 0 {
+		query.WriteString("(" + strings.Join(callArgs, ", ") + ")")
+	}
+	if selection := selectionSet(f.Type.named(), typesByName); selection != "" {
+		query.WriteString(" " + selection)
+	}
+	query.WriteString(" }")
+
+	description := f.Description
+	if description == "" {
+		description = fmt.Sprintf("GraphQL %s %s", operation, f.Name)
+	}
+
+	request := template.Request
+	request.GraphQL = &types.GraphQLRequest{
+		Query:     query.String(),
+		Variables: variables,
+	}
+
+	return types.Tool{
+		Name:        template.Name + f.Name,
+		Description: description,
+		Args:        args,
+		Request:     request,
+	}, true
+}
+
+// selectionSet selects the scalar and enum fields without arguments of an object type, and of each
+// possible type of a union.
+func selectionSet(t typeRef, typesByName map[string]fullType) string {
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		names := scalarFields(typesByName[t.Name])
+		if len(names) == 0 {
+			return "{ __typename }"
+		}
+		return "{ " + strings.Join(names, " ") + " }"
+	case "UNION":
+		selections := []string{"__typename"}
+		for _, possible := range typesByName[t.Name].PossibleTypes {
+			if names := scalarFields(typesByName[possible.Name]); len(names) > 0 {
+				selections = append(selections, "... on "+possible.Name+" { "+strings.Join(names, " ")+" }")
+			}
+		}
+		return "{ " + strings.Join(selections, " ") + " }"
+	default:
+		return ""
+	}
+}
+
+func scalarFields(t fullType) []string {
+	var names []string
+	for _, f := range t.Fields {
+		kind := f.Type.named().Kind
+		if len(f.Args) == 0 && (kind == "SCALAR" || kind == "ENUM") {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// isScalarInput reports whether a value of the type can be passed from a single scalar arg.
+func isScalarInput(t typeRef) bool {
+	if t.Kind == "NON_NULL" {
+		t = *t.OfType
+	}
+	return t.Kind == "SCALAR" || t.Kind == "ENUM"
+}
+
+func argType(scalar string) string {
+	switch scalar {
+	case "Int":
+		return "int"
+	case "Float":
+		return "float"
+	case "Boolean":
+		return "bool"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,96 @@
+package graphql_test
+
+import (
+	"github.com/AdamShannag/api-mcp-server/pkg/graphql"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestToolsFromIntrospection(t *testing.T) {
+	data, err := os.ReadFile("testdata/introspection.json")
+	assert.NoError(t, err)
+
+	template := types.Tool{
+		Name: "gitlab_",
+		Request: types.Request{
+			Kind:     "graphql",
+			Host:     "gitlab.com",
+			Endpoint: "/api/graphql",
+			Secure:   true,
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+			GraphQL:  &types.GraphQLRequest{Introspection: "schema.json"},
+		},
+	}
+
+	tools, err := graphql.ToolsFromIntrospection(data, template)
+	assert.NoError(t, err)
+
+	byName := make(map[string]types.Tool)
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	assert.Len(t, byName, 3)
+	assert.NotContains(t, byName, "gitlab_createIssue")
+
+	project := byName["gitlab_project"]
+	assert.Equal(t, "Find a project by its full path.", project.Description)
+	assert.Equal(t, []types.Arg{{Name: "fullPath", Description: "Full path of the project", Required: true, Type: "string"}}, project.Args)
+	assert.Equal(t, "query($fullPath: ID!) { project(fullPath: $fullPath) { id name visibility } }", project.Request.GraphQL.Query)
+	assert.Equal(t, []string{"fullPath"}, project.Request.GraphQL.Variables)
+	assert.Equal(t, "gitlab.com", project.Request.Host)
+	assert.Equal(t, "/api/graphql", project.Request.Endpoint)
+	assert.Empty(t, project.Request.GraphQL.Introspection)
+
+	projects := byName["gitlab_projects"]
+	assert.Equal(t, "GraphQL query projects", projects.Description)
+	assert.Equal(t, []types.Arg{{Name: "first", Type: "int"}}, projects.Args)
+	assert.Equal(t, "query($first: Int) { projects(first: $first) { id name visibility } }", projects.Request.GraphQL.Query)
+
+	archive := byName["gitlab_archiveProject"]
+	assert.Equal(t, []types.Arg{
+		{Name: "id", Required: true, Type: "string"},
+		{Name: "force", Required: false, Type: "bool"},
+	}, archive.Args)
+	assert.Equal(t, "mutation($id: ID!, $force: Boolean!) { archiveProject(id: $id, force: $force) }", archive.Request.GraphQL.Query)
+}
+
+func TestToolsFromIntrospection_Invalid(t *testing.T) {
+	_, err := graphql.ToolsFromIntrospection([]byte("{oops"), types.Tool{})
+	assert.Error(t, err)
+
+	_, err = graphql.ToolsFromIntrospection([]byte(`{"data":{}}`), types.Tool{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no __schema")
+}
+
+func TestToolsFromIntrospection_Union(t *testing.T) {
+	data := `{"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "search", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "UNION", "name": "Result"}}}
+		]},
+		{"kind": "UNION", "name": "Result", "possibleTypes": [{"name": "Issue"}, {"name": "Empty"}]},
+		{"kind": "OBJECT", "name": "Issue", "fields": [
+			{"name": "title", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+		]},
+		{"kind": "OBJECT", "name": "Empty", "fields": []}
+	]}}`
+
+	tools, err := graphql.ToolsFromIntrospection([]byte(data), types.Tool{})
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "query { search { __typename ... on Issue { title } } }", tools[0].Request.GraphQL.Query)
+}
+
+func TestToolsFromIntrospection_MissingOfType(t *testing.T) {
+	data := `{"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "project", "args": [{"name": "id", "type": {"kind": "NON_NULL"}}], "type": {"kind": "SCALAR", "name": "String"}}
+		]}
+	]}}`
+
+	_, err := graphql.ToolsFromIntrospection([]byte(data), types.Tool{})
+	assert.ErrorContains(t, err, "invalid introspection: arg id of field Query.project: NON_NULL type has no ofType")
+}
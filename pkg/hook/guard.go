@@ -0,0 +1,177 @@
+package hook
+
+import (
+	"fmt"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"math"
+)
+
+const (
+	// guardFunc is the builtin wrapping the operands of + and *. It is not a valid identifier, so scripts
+	// cannot refer to it.
+	guardFunc = "$guard"
+
+	budgetLocal = "budget"
+
+	// elemSize is the size counted for each element of a list or tuple.
+	elemSize = 16
+)
+
+// budget counts the bytes a call allocates through concatenation and repetition.
+type budget struct {
+	thread *starlark.Thread
+	used   int64
+	max    int64
+}
+
+func (b *budget) charge(n int64) error {
+	if n > b.max-b.used {
+		return fmt.Errorf("memory limit of %d bytes exceeded", b.max)
+	}
+	b.used += n
+	return nil
+}
+
+// guardOperators wraps the operands of +, *, += and *= in a call to guardFunc, so the size of their result
+// is counted before it is allocated.
+func guardOperators(f *syntax.File) {
+	syntax.Walk(f, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.BinaryExpr:
+			if n.Op == syntax.PLUS || n.Op == syntax.STAR {
+				n.X = guardExpr(n.X)
+				n.Y = guardExpr(n.Y)
+			}
+		case *syntax.AssignStmt:
+			if n.Op == syntax.PLUS_EQ || n.Op == syntax.STAR_EQ {
+				n.RHS = guardExpr(n.RHS)
+			}
+		}
+		return true
+	})
+}
+
+func guardExpr(x syntax.Expr) syntax.Expr {
+	start, end := x.Span()
+	return &syntax.CallExpr{
+		Fn:     &syntax.Ident{NamePos: start, Name: guardFunc},
+		Lparen: start,
+		Args:   []syntax.Expr{x},
+		Rparen: end,
+	}
+}
+
+var guardBuiltin = starlark.NewBuiltin("guard", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	b := thread.Local(budgetLocal).(*budget)
+	g := guarded{Value: args[0], budget: b}
+	if _, ok := args[0].(starlark.Iterable); ok {
+		return guardedIterable{g}, nil
+	}
+	return g, nil
+})
+
+// guarded is an operand of + or *. It checks the size of the result against the budget before computing it.
+type guarded struct {
+	starlark.Value
+	budget *budget
+}
+
+func (g guarded) Binary(op syntax.Token, y starlark.Value, side starlark.Side) (starlark.Value, error) {
+	x, y := g.Value, unguard(y)
+	if side == starlark.Right {
+		x, y = y, x
+	}
+	if err := g.budget.charge(resultSize(op, x, y)); err != nil {
+		return nil, err
+	}
+	return starlark.Binary(op, x, y)
+}
+
+// guardedIterable is an iterable operand, which += iterates to extend a list.
+type guardedIterable struct {
+	guarded
+}
+
+// Iterate counts the elements it yields and iterates over a copy, so that l += l does not extend the list
+// it is iterating.
+func (g guardedIterable) Iterate() starlark.Iterator {
+	if seq, ok := g.Value.(starlark.Indexable); ok {
+		if err := g.budget.charge(int64(seq.Len()) * elemSize); err != nil {
+			g.budget.thread.Cancel(err.Error())
+			return starlark.Tuple(nil).Iterate()
+		}
+		elems := make(starlark.Tuple, seq.Len())
+		for i := range elems {
+			elems[i] = seq.Index(i)
+		}
+		return elems.Iterate()
+	}
+
+	var elems starlark.Tuple
+	iter := g.Value.(starlark.Iterable).Iterate()
+	defer iter.Done()
+	var v starlark.Value
+	for iter.Next(&v) {
+		if err := g.budget.charge(elemSize); err != nil {
+			g.budget.thread.Cancel(err.Error())
+			break
+		}
+		elems = append(elems, v)
+	}
+	return elems.Iterate()
+}
+
+func unguard(v starlark.Value) starlark.Value {
+	switch v := v.(type) {
+	case guarded:
+		return v.Value
+	case guardedIterable:
+		return v.Value
+	}
+	return v
+}
+
+func resultSize(op syntax.Token, x, y starlark.Value) int64 {
+	if op == syntax.PLUS {
+		return size(x) + size(y)
+	}
+
+	seq, n := x, y
+	if _, ok := x.(starlark.Int); ok {
+		if _, ok := y.(starlark.Int); ok {
+			return size(x) + size(y)
+		}
+		seq, n = y, x
+	}
+	count, ok := n.(starlark.Int)
+	if !ok {
+		return 0
+	}
+	c, ok := count.Int64()
+	if !ok {
+		c = math.MaxInt64
+	}
+	s := size(seq)
+	if c <= 0 || s == 0 {
+		return 0
+	}
+	if c > math.MaxInt64/s {
+		return math.MaxInt64
+	}
+	return s * c
+}
+
+func size(v starlark.Value) int64 {
+	switch v := v.(type) {
+	case starlark.String:
+		return int64(len(v))
+	case starlark.Bytes:
+		return int64(len(v))
+	case starlark.Int:
+		return int64(v.BigInt().BitLen()/8 + 1)
+	case starlark.Indexable:
+		return int64(v.Len()) * elemSize
+	}
+	return 0
+}
@@ -0,0 +1,231 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"log/slog"
+	"maps"
+	"time"
+)
+
+const (
+	PreRequestFunc   = "pre_request"
+	PostResponseFunc = "post_response"
+
+	defaultMaxSteps      = 1_000_000
+	defaultTimeout       = time.Second
+	defaultMaxResultSize = 1 << 20
+	defaultMaxMemory     = 64 << 20
+
+	// bodyArg is the arg holding a body set by a pre-request hook when the request has no body arg.
+	bodyArg = "_hook_body"
+)
+
+// Hook is a compiled Starlark script exposing a pre_request or post_response function.
+// Each call runs in its own thread bounded by execution steps, a timeout, the size of the data it returns and
+// the bytes it allocates through +, *, += and *= on strings, bytes, lists, tuples and ints. Other builtins,
+// such as str.join or str.replace, are not counted.
+type Hook struct {
+	name          string
+	fn            starlark.Callable
+	maxSteps      uint64
+	timeout       time.Duration
+	maxResultSize int64
+	maxMemory     int64
+}
+
+// Compile executes the script top-level and looks up the function to call.
+func Compile(name, src, function string, cfg types.Hook) (*Hook, error) {
+	h := &Hook{
+		name:          name,
+		maxSteps:      cfg.MaxSteps,
+		timeout:       defaultTimeout,
+		maxResultSize: cfg.MaxResultSize,
+		maxMemory:     cfg.MaxMemory,
+	}
+	if h.maxSteps == 0 {
+		h.maxSteps = defaultMaxSteps
+	}
+	if h.maxResultSize == 0 {
+		h.maxResultSize = defaultMaxResultSize
+	}
+	if h.maxMemory == 0 {
+		h.maxMemory = defaultMaxMemory
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("hook %s: invalid timeout: %w", name, err)
+		}
+		h.timeout = timeout
+	}
+
+	f, err := (&syntax.FileOptions{}).Parse(name, src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", name, err)
+	}
+	guardOperators(f)
+
+	predeclared := starlark.StringDict{
+		"json":    starlarkjson.Module,
+		guardFunc: guardBuiltin,
+	}
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", name, err)
+	}
+	globals, err := prog.Init(h.newThread(context.Background()), predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", name, err)
+	}
+	globals.Freeze()
+
+	fn, ok := globals[function].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("hook %s: function %s is not defined", name, function)
+	}
+	h.fn = fn
+
+	return h, nil
+}
+
+// PreRequest lets the script read and modify the args, method, host, endpoint, headers and body before
+// the request is executed. The script may mutate the dict it receives or return a new one.
+func (h *Hook) PreRequest(ctx context.Context, request types.Request, args map[string]string) (types.Request, map[string]string, error) {
+	in := map[string]any{
+		"args":     toAnyMap(args),
+		"headers":  toAnyMap(request.Headers),
+		"method":   request.Method,
+		"host":     request.Host,
+		"endpoint": request.Endpoint,
+		"secure":   request.Secure,
+		"body":     args[request.Body],
+	}
+
+	out, err := h.call(ctx, in)
+	if err != nil {
+		return request, args, err
+	}
+
+	obj, ok := out.(map[string]any)
+	if !ok {
+		return request, args, fmt.Errorf("hook %s: %s must return a dict", h.name, PreRequestFunc)
+	}
+
+	// Keys missing from the returned dict keep their value.
+	newArgs := args
+	if val, ok := obj["args"]; ok {
+		newArgs = toStringMap(val)
+	}
+	if val, ok := obj["headers"]; ok {
+		request.Headers = toStringMap(val)
+	}
+	setString(obj, "method", &request.Method)
+	setString(obj, "host", &request.Host)
+	setString(obj, "endpoint", &request.Endpoint)
+	if val, ok := obj["secure"].(bool); ok {
+		request.Secure = val
+	}
+
+	if body, ok := obj["body"].(string); ok && body != args[request.Body] {
+		if request.Body == "" {
+			request.Body = bodyArg
+		}
+		if _, ok := obj["args"]; !ok {
+			newArgs = maps.Clone(args)
+		}
+		newArgs[request.Body] = body
+	}
+
+	return request, newArgs, nil
+}
+
+func setString(obj map[string]any, key string, dst *string) {
+	if val, ok := obj[key].(string); ok {
+		*dst = val
+	}
+}
+
+// PostResponse lets the script transform the tool result. It receives the decoded result and may return
+// a string, used as-is, or any other value, encoded as JSON.
+func (h *Hook) PostResponse(ctx context.Context, result string) (string, error) {
+	var in any = result
+	var decoded any
+	if err := json.Unmarshal([]byte(result), &decoded); err == nil {
+		in = decoded
+	}
+
+	out, err := h.call(ctx, in)
+	if err != nil {
+		return "", err
+	}
+
+	if s, ok := out.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("hook %s: failed to encode result: %w", h.name, err)
+	}
+	return string(data), nil
+}
+
+func (h *Hook) call(ctx context.Context, in any) (any, error) {
+	arg, err := toStarlark(in)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", h.name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	thread := h.newThread(ctx)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	val, err := starlark.Call(thread, h.fn, starlark.Tuple{arg}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", h.name, err)
+	}
+	if val == starlark.None {
+		val = arg
+	}
+
+	out, err := fromStarlark(val)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", h.name, err)
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("hook %s: %w", h.name, err)
+	}
+	if int64(len(data)) > h.maxResultSize {
+		return nil, fmt.Errorf("hook %s: result of %d bytes exceeds the limit of %d bytes", h.name, len(data), h.maxResultSize)
+	}
+	return out, nil
+}
+
+func (h *Hook) newThread(ctx context.Context) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: h.name,
+		Print: func(_ *starlark.Thread, msg string) {
+			slog.DebugContext(ctx, "hook output", slog.String("hook", h.name), slog.String("message", msg))
+		},
+	}
+	thread.SetMaxExecutionSteps(h.maxSteps)
+	thread.SetLocal(budgetLocal, &budget{thread: thread, max: h.maxMemory})
+	return thread
+}
@@ -0,0 +1,175 @@
+package hook_test
+
+import (
+	"context"
+	"github.com/AdamShannag/api-mcp-server/pkg/hook"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPreRequest(t *testing.T) {
+	src := `
+def pre_request(req):
+    req["headers"]["X-Signature"] = "sig-" + req["args"]["id"]
+    req["args"]["id"] = req["args"]["id"].upper()
+    req["endpoint"] = req["endpoint"] + "/v2"
+    req["body"] = json.encode({"id": req["args"]["id"]})
+`
+	h, err := hook.Compile("sign", src, hook.PreRequestFunc, types.Hook{})
+	require.NoError(t, err)
+
+	req := types.Request{
+		Method:   "POST",
+		Host:     "example.com",
+		Endpoint: "/items",
+		Headers:  map[string]string{"Accept": "application/json"},
+	}
+
+	out, args, err := h.PreRequest(context.Background(), req, map[string]string{"id": "abc"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/items/v2", out.Endpoint)
+	assert.Equal(t, "POST", out.Method)
+	assert.Equal(t, "example.com", out.Host)
+	assert.Equal(t, map[string]string{"Accept": "application/json", "X-Signature": "sig-abc"}, out.Headers)
+	assert.Equal(t, "ABC", args["id"])
+	assert.NotEmpty(t, out.Body)
+	assert.Equal(t, `{"id":"ABC"}`, args[out.Body])
+}
+
+func TestPreRequest_PartialResult(t *testing.T) {
+	src := `
+def pre_request(req):
+    return {"args": {"id": "xyz"}}
+`
+	h, err := hook.Compile("partial", src, hook.PreRequestFunc, types.Hook{})
+	require.NoError(t, err)
+
+	req := types.Request{
+		Method:   "GET",
+		Host:     "example.com",
+		Endpoint: "/items/:id",
+		Secure:   true,
+		Headers:  map[string]string{"Accept": "application/json"},
+	}
+
+	out, args, err := h.PreRequest(context.Background(), req, map[string]string{"id": "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, req, out)
+	assert.Equal(t, map[string]string{"id": "xyz"}, args)
+}
+
+func TestPostResponse(t *testing.T) {
+	src := `
+def post_response(result):
+    items = json.decode(result["body"])
+    return [i["title"] for i in items if i["done"]]
+`
+	h, err := hook.Compile("filter", src, hook.PostResponseFunc, types.Hook{})
+	require.NoError(t, err)
+
+	out, err := h.PostResponse(context.Background(),
+		`{"status_code":200,"body":"[{\"title\":\"a\",\"done\":true},{\"title\":\"b\",\"done\":false}]"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `["a"]`, out)
+
+	h, err = hook.Compile("text", `def post_response(result): return "summary"`, hook.PostResponseFunc, types.Hook{})
+	require.NoError(t, err)
+
+	out, err = h.PostResponse(context.Background(), `{}`)
+	require.NoError(t, err)
+	assert.Equal(t, "summary", out)
+}
+
+func TestLimits(t *testing.T) {
+	loop := `
+def post_response(result):
+    n = 0
+    for i in range(100000000):
+        n += i
+    return n
+`
+	h, err := hook.Compile("steps", loop, hook.PostResponseFunc, types.Hook{MaxSteps: 1000})
+	require.NoError(t, err)
+	_, err = h.PostResponse(context.Background(), `{}`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "too many steps")
+
+	h, err = hook.Compile("timeout", loop, hook.PostResponseFunc, types.Hook{MaxSteps: 1 << 40, Timeout: "10ms"})
+	require.NoError(t, err)
+	_, err = h.PostResponse(context.Background(), `{}`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "deadline exceeded")
+
+	h, err = hook.Compile("size", `def post_response(result): return "x" * 2048`, hook.PostResponseFunc, types.Hook{MaxResultSize: 1024})
+	require.NoError(t, err)
+	_, err = h.PostResponse(context.Background(), `{}`)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the limit of 1024 bytes")
+}
+
+func TestMemoryLimit(t *testing.T) {
+	scripts := map[string]string{
+		"repeat": `def post_response(result): return len("x" * (1 << 29))`,
+		"concat": `
+def post_response(result):
+    s = "x"
+    for i in range(40):
+        s = s + s
+    return len(s)
+`,
+		"extend": `
+def post_response(result):
+    l = [1]
+    for i in range(40):
+        l += l
+    return len(l)
+`,
+		"top-level": `
+x = "x" * (1 << 29)
+def post_response(result): return result
+`,
+	}
+	for name, src := range scripts {
+		t.Run(name, func(t *testing.T) {
+			h, err := hook.Compile(name, src, hook.PostResponseFunc, types.Hook{MaxMemory: 1 << 20})
+			if err == nil {
+				_, err = h.PostResponse(context.Background(), `{}`)
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "memory limit of 1048576 bytes exceeded")
+		})
+	}
+
+	h, err := hook.Compile("operators", `
+def post_response(result):
+    l = [1, 2]
+    l += l
+    l += (3,)
+    t = (1,) * 2 + (2,)
+    s = "ab" * 2 + "c"
+    s += "d"
+    n = 2 * 3 + 1
+    n *= 2
+    return [l, t, s, n, 1.5 * 2]
+`, hook.PostResponseFunc, types.Hook{MaxMemory: 1024})
+	require.NoError(t, err)
+	out, err := h.PostResponse(context.Background(), `{}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[[1, 2, 1, 2, 3], [1, 1, 2], "ababcd", 14, 3.0]`, out)
+}
+
+func TestCompileErrors(t *testing.T) {
+	_, err := hook.Compile("syntax", "def pre_request(:", hook.PreRequestFunc, types.Hook{})
+	assert.Error(t, err)
+
+	_, err = hook.Compile("missing", "x = 1", hook.PreRequestFunc, types.Hook{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not defined")
+
+	_, err = hook.Compile("timeout", "def pre_request(req): pass", hook.PreRequestFunc, types.Hook{Timeout: "soon"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timeout")
+}
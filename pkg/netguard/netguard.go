@@ -0,0 +1,181 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+var (
+	// ErrHostNotAllowed is returned for requests to hosts missing from an allow-list.
+	ErrHostNotAllowed = errors.New("host is not allowed")
+
+	// ErrAddressBlocked is returned for connections to private, loopback and link-local addresses.
+	ErrAddressBlocked = errors.New("address is blocked")
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not covered by netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+type Option func(*Guard)
+
+// Guard protects outbound connections against server-side request forgery. Requests must target a host
+// of the global allow-list, when one is set, and of the allow-list carried by their context. Connections
+// to loopback, private, link-local and unspecified addresses are refused after DNS resolution, unless
+// the address is in an allowed network.
+type Guard struct {
+	hosts    []string
+	networks []netip.Prefix
+}
+
+func New(opts ...Option) *Guard {
+	g := &Guard{}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// WithAllowedHosts restricts requests to hosts matching the patterns. A pattern is a host name or IP,
+// optionally with a port; "*.example.com" matches the subdomains of example.com.
+func WithAllowedHosts(patterns ...string) Option {
+	return func(g *Guard) {
+		g.hosts = append(g.hosts, patterns...)
+	}
+}
+
+// WithAllowedNetworks allows connections to addresses in the given networks, even when they are private.
+func WithAllowedNetworks(networks ...netip.Prefix) Option {
+	return func(g *Guard) {
+		g.networks = append(g.networks, networks...)
+	}
+}
+
+type hostsKey struct{}
+
+// ContextWithHosts returns a context restricting the requests made with it to hosts matching the
+// patterns, in addition to the global allow-list. An empty list adds no restriction.
+func ContextWithHosts(ctx context.Context, patterns []string) context.Context {
+	if len(patterns) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, hostsKey{}, patterns)
+}
+
+// Check returns ErrHostNotAllowed when the host of u is missing from the global allow-list or the
+// allow-list of ctx.
+func (g *Guard) Check(ctx context.Context, u *url.URL) error {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	lists := [][]string{g.hosts}
+	if hosts, ok := ctx.Value(hostsKey{}).([]string); ok {
+		lists = append(lists, hosts)
+	}
+	for _, patterns := range lists {
+		if len(patterns) > 0 && !matchAny(patterns, u.Hostname(), port) {
+			return fmt.Errorf("%w: %s", ErrHostNotAllowed, u.Host)
+		}
+	}
+	return nil
+}
+
+// DialContext connects to addr, refusing blocked addresses after resolution.
+func (g *Guard) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{ControlContext: g.control}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// Transport returns a copy of base, or of http.DefaultTransport when base is nil, checking the host of
+// every request, including redirects, and dialing with DialContext. Proxies are disabled, as the guard
+// could not check the addresses a proxy connects to.
+func (g *Guard) Transport(base *http.Transport) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	transport.Proxy = nil
+	transport.DialContext = g.DialContext
+	return &roundTripper{guard: g, next: transport}
+}
+
+// control runs before every connection, with the resolved address being connected to.
+func (g *Guard) control(_ context.Context, _, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAddressBlocked, address)
+	}
+	if ip := addrPort.Addr().Unmap(); blocked(ip) && !g.allowedNetwork(ip) {
+		return fmt.Errorf("%w: %s", ErrAddressBlocked, ip)
+	}
+	return nil
+}
+
+func (g *Guard) allowedNetwork(ip netip.Addr) bool {
+	for _, network := range g.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func blocked(ip netip.Addr) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+func matchAny(patterns []string, host, port string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, host, port) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, host, port string) bool {
+	patternHost, patternPort := pattern, ""
+	if h, p, err := net.SplitHostPort(pattern); err == nil {
+		patternHost, patternPort = h, p
+	}
+	if patternPort != "" && patternPort != port {
+		return false
+	}
+
+	patternHost = strings.ToLower(strings.Trim(patternHost, "[]"))
+	host = strings.ToLower(host)
+	if suffix, ok := strings.CutPrefix(patternHost, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return patternHost == host
+}
+
+type roundTripper struct {
+	guard *Guard
+	next  http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := rt.guard.Check(req.Context(), req.URL); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,112 @@
+package netguard_test
+
+import (
+	"context"
+	"github.com/AdamShannag/api-mcp-server/pkg/netguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestGuard_Check(t *testing.T) {
+	g := netguard.New(netguard.WithAllowedHosts("api.example.com", "*.internal.io", "files.example.com:8443"))
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://api.example.com/v1", true},
+		{"http://API.example.com:8080/v1", true},
+		{"https://svc.internal.io/", true},
+		{"https://internal.io/", false},
+		{"https://files.example.com:8443/", true},
+		{"https://files.example.com/", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			err = g.Check(context.Background(), u)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, netguard.ErrHostNotAllowed)
+			}
+		})
+	}
+}
+
+func TestGuard_Check_ContextHosts(t *testing.T) {
+	u, _ := url.Parse("https://other.example.com/")
+
+	assert.NoError(t, netguard.New().Check(context.Background(), u))
+
+	ctx := netguard.ContextWithHosts(context.Background(), []string{"api.example.com"})
+	assert.ErrorIs(t, netguard.New().Check(ctx, u), netguard.ErrHostNotAllowed)
+
+	g := netguard.New(netguard.WithAllowedHosts("*.example.com"))
+	assert.ErrorIs(t, g.Check(ctx, u), netguard.ErrHostNotAllowed)
+	assert.NoError(t, g.Check(netguard.ContextWithHosts(context.Background(), []string{"other.example.com"}), u))
+}
+
+func TestGuard_Transport_BlocksPrivateAddresses(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: netguard.New().Transport(nil)}
+	_, err := client.Get(upstream.URL)
+	assert.ErrorIs(t, err, netguard.ErrAddressBlocked)
+
+	client = &http.Client{Transport: netguard.New(
+		netguard.WithAllowedNetworks(netip.MustParsePrefix("127.0.0.0/8")),
+	).Transport(nil)}
+	resp, err := client.Get(upstream.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGuard_Transport_ChecksRedirects(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	client := &http.Client{Transport: netguard.New(
+		netguard.WithAllowedHosts(u.Host),
+		netguard.WithAllowedNetworks(netip.MustParsePrefix("127.0.0.0/8")),
+	).Transport(nil)}
+
+	_, err := client.Get(upstream.URL)
+	assert.ErrorIs(t, err, netguard.ErrHostNotAllowed)
+}
+
+func TestGuard_Transport_NoProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	proxied := false
+	base := &http.Transport{Proxy: func(*http.Request) (*url.URL, error) {
+		proxied = true
+		return nil, nil
+	}}
+	client := &http.Client{Transport: netguard.New(
+		netguard.WithAllowedNetworks(netip.MustParsePrefix("127.0.0.0/8")),
+	).Transport(base)}
+
+	resp, err := client.Get(upstream.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.False(t, proxied)
+}
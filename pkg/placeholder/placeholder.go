@@ -0,0 +1,75 @@
+package placeholder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Func resolves a single placeholder. For {{arg name}} the "arg" Func is called with "name".
+type Func func(arg string) (string, error)
+
+// escapedOpen is the placeholder Expand replaces with a literal "{{".
+const escapedOpen = `{{"{{"}}`
+
+// Escape returns s with every "{{" escaped, so that Expand outputs s as-is instead of expanding it.
+func Escape(s string) string {
+	return strings.ReplaceAll(s, "{{", escapedOpen)
+}
+
+// Expand replaces placeholders like {{name arg}} using the matching Func, and {{"{{"}} with "{{".
+// Placeholders with no matching Func, and unterminated ones, are left untouched.
+func Expand(in string, funcs map[string]Func) (string, error) {
+	if !strings.Contains(in, "{{") {
+		return in, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(in))
+
+	i := 0
+	for i < len(in) {
+		start := strings.Index(in[i:], "{{")
+		if start == -1 {
+			b.WriteString(in[i:])
+			break
+		}
+		start += i
+		b.WriteString(in[i:start])
+		end := strings.Index(in[start:], "}}")
+		if end == -1 {
+			b.WriteString(in[start:])
+			break
+		}
+		end += start
+
+		if in[start:end+2] == escapedOpen {
+			b.WriteString("{{")
+			i = end + 2
+			continue
+		}
+
+		name, arg := split(in[start+2 : end])
+		fn, ok := funcs[name]
+		if !ok {
+			b.WriteString(in[start : end+2])
+			i = end + 2
+			continue
+		}
+
+		val, err := fn(arg)
+		if err != nil {
+			return "", fmt.Errorf("placeholder %q: %w", in[start:end+2], err)
+		}
+		b.WriteString(val)
+		i = end + 2
+	}
+	return b.String(), nil
+}
+
+func split(content string) (string, string) {
+	content = strings.TrimSpace(content)
+	if idx := strings.IndexAny(content, " \t"); idx != -1 {
+		return content[:idx], strings.TrimSpace(content[idx+1:])
+	}
+	return content, ""
+}
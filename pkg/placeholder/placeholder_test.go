@@ -0,0 +1,47 @@
+package placeholder_test
+
+import (
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	funcs := map[string]placeholder.Func{
+		"arg": func(name string) (string, error) {
+			if name == "missing" {
+				return "", errors.New("unknown arg")
+			}
+			return "<" + name + ">", nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "no placeholders", input: "plain", expected: "plain"},
+		{name: "single placeholder", input: "W/{{arg etag}}", expected: "W/<etag>"},
+		{name: "extra spaces", input: "{{  arg   tenant  }}", expected: "<tenant>"},
+		{name: "multiple placeholders", input: "{{arg a}}-{{arg b}}", expected: "<a>-<b>"},
+		{name: "unknown func untouched", input: "{{env HOME}} {{arg a}}", expected: "{{env HOME}} <a>"},
+		{name: "unterminated", input: "{{arg a", expected: "{{arg a"},
+		{name: "func error", input: "{{arg missing}}", wantErr: true},
+		{name: "escaped", input: placeholder.Escape("{{arg a}}") + "{{arg b}}", expected: "{{arg a}}<b>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := placeholder.Expand(tt.input, funcs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/resolver"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type resolveInput struct {
+	Arg   types.Arg `json:"arg"`
+	Value string    `json:"value"`
+}
+
+type resolveOutput struct {
+	Value string `json:"value"`
+	Error string `json:"error,omitempty"`
+}
+
+type executeInput struct {
+	Request types.Request     `json:"request"`
+	Args    map[string]string `json:"args"`
+}
+
+type executeOutput struct {
+	Result        string               `json:"result"`
+	Error         string               `json:"error,omitempty"`
+	ErrorResponse *types.ErrorResponse `json:"errorResponse,omitempty"`
+}
+
+// ArgResolver returns a resolver for the plugin arg types. Values are exposed to clients as strings and
+// passed to the plugin resolve function, which validates or transforms them.
+func (p *Plugin) ArgResolver() resolver.ArgResolver {
+	return &argResolver{plugin: p}
+}
+
+// Executor returns an executor for the plugin request kinds.
+func (p *Plugin) Executor() request.Executor {
+	return &executor{plugin: p}
+}
+
+type argResolver struct {
+	plugin *Plugin
+	base   resolver.StringResolver
+}
+
+func (r *argResolver) Resolve(ctx context.Context, req resolver.CallToolRequest, arg types.Arg) (string, error) {
+	val, err := r.base.Resolve(ctx, req, arg)
+	if err != nil {
+		return "", err
+	}
+
+	var out resolveOutput
+	if err = r.plugin.call(ctx, resolveFunc, resolveInput{Arg: arg, Value: val}, &out); err != nil {
+		return "", err
+	}
+	if out.Error != "" {
+		return "", errors.New(out.Error)
+	}
+	return out.Value, nil
+}
+
+func (r *argResolver) ToToolOption(arg types.Arg) mcp.ToolOption {
+	return r.base.ToToolOption(arg)
+}
+
+type executor struct {
+	plugin *Plugin
+}
+
+func (e *executor) Execute(ctx context.Context, req types.Request, args map[string]string) (string, error) {
+	var out executeOutput
+	if err := e.plugin.call(ctx, executeFunc, executeInput{Request: req, Args: args}, &out); err != nil {
+		return "", err
+	}
+	if out.ErrorResponse != nil {
+		return "", &request.StatusError{Message: out.Error, Response: *out.ErrorResponse}
+	}
+	if out.Error != "" {
+		return "", errors.New(out.Error)
+	}
+	return out.Result, nil
+}
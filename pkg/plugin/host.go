@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tetratelabs/wazero/api"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// maxHTTPResponseSize bounds the response body returned to a plugin by http_request.
+const maxHTTPResponseSize = 10 << 20
+
+type httpRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type httpResponse struct {
+	StatusCode int               `json:"status_code,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func (p *Plugin) hostLog(ctx context.Context, module api.Module, ptr, size uint32) {
+	msg, ok := module.Memory().Read(ptr, size)
+	if !ok {
+		return
+	}
+	slog.InfoContext(ctx, "plugin log", slog.String("plugin", p.name), slog.String("message", string(msg)))
+}
+
+// hostHTTPRequest performs the JSON encoded httpRequest and returns the httpResponse written to memory
+// allocated by the module. Failures are reported in the error field of the response.
+func (p *Plugin) hostHTTPRequest(ctx context.Context, module api.Module, ptr, size uint32) uint64 {
+	var resp httpResponse
+	if input, ok := module.Memory().Read(ptr, size); !ok {
+		resp.Error = "input out of range"
+	} else {
+		resp = p.doHTTPRequest(ctx, input)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Errorf("plugin %s: failed to encode http response: %w", p.name, err))
+	}
+
+	outPtr, err := writeInput(ctx, module, data)
+	if err != nil {
+		panic(fmt.Errorf("plugin %s: %w", p.name, err))
+	}
+	return uint64(outPtr)<<32 | uint64(len(data))
+}
+
+func (p *Plugin) doHTTPRequest(ctx context.Context, input []byte) httpResponse {
+	if !p.allowHTTP {
+		return httpResponse{Error: "http requests are not allowed for this plugin"}
+	}
+
+	var in httpRequest
+	if err := json.Unmarshal(input, &in); err != nil {
+		return httpResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, in.Method, in.URL, bytes.NewBufferString(in.Body))
+	if err != nil {
+		return httpResponse{Error: err.Error()}
+	}
+	for k, v := range in.Headers {
+		req.Header.Set(k, v)
+	}
+
+	slog.Info("executing plugin http request",
+		slog.String("plugin", p.name),
+		slog.Group("request",
+			slog.String("method", req.Method),
+			slog.String("url", in.URL),
+		),
+	)
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return httpResponse{Error: err.Error()}
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxHTTPResponseSize+1))
+	if err != nil {
+		return httpResponse{Error: fmt.Sprintf("failed to read response: %v", err)}
+	}
+	if len(body) > maxHTTPResponseSize {
+		return httpResponse{Error: "response too large"}
+	}
+
+	headers := make(map[string]string, len(res.Header))
+	for k := range res.Header {
+		headers[k] = res.Header.Get(k)
+	}
+
+	return httpResponse{StatusCode: res.StatusCode, Headers: headers, Body: string(body)}
+}
@@ -0,0 +1,262 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// A plugin is a WebAssembly module exporting:
+//
+//	memory
+//	alloc(size i32) i32               allocates size bytes for an input written by the host
+//	resolve(ptr i32, len i32) i64     resolves an arg of one of the plugin arg types (optional)
+//	execute(ptr i32, len i32) i64     executes a request of one of the plugin kinds (optional)
+//
+// resolve and execute take a JSON document and return one, packed as ptr<<32 | len.
+// The host module "api_mcp" provides log(ptr, len) and http_request(ptr, len) i64 using the same conventions.
+const (
+	hostModule = "api_mcp"
+
+	allocFunc   = "alloc"
+	resolveFunc = "resolve"
+	executeFunc = "execute"
+
+	defaultTimeout   = 5 * time.Second
+	defaultMaxMemory = 16 << 20
+	wasmPageSize     = 64 << 10
+)
+
+type Option func(*Plugin)
+
+// Plugin is a loaded WebAssembly plugin. Calls are serialized on a single module instance, which is
+// re-instantiated if a call is interrupted by its timeout.
+type Plugin struct {
+	name       string
+	argTypes   []string
+	kinds      []string
+	timeout    time.Duration
+	allowHTTP  bool
+	httpClient *http.Client
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+
+	mu     sync.Mutex
+	module api.Module
+}
+
+// WithHttpClient sets the client used by the http_request host function.
+func WithHttpClient(client *http.Client) Option {
+	return func(p *Plugin) {
+		p.httpClient = client
+	}
+}
+
+// Load compiles the plugin module and checks it exports the functions its config requires.
+func Load(ctx context.Context, cfg types.Plugin, opts ...Option) (*Plugin, error) {
+	p := &Plugin{
+		name:       cfg.Name,
+		argTypes:   cfg.ArgTypes,
+		kinds:      cfg.Kinds,
+		timeout:    defaultTimeout,
+		allowHTTP:  cfg.AllowHTTP,
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: invalid timeout: %w", cfg.Name, err)
+		}
+		p.timeout = timeout
+	}
+
+	maxMemory := cfg.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+
+	code, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: failed to read module: %w", cfg.Name, err)
+	}
+
+	p.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32((maxMemory+wasmPageSize-1)/wasmPageSize)),
+	)
+
+	if err = p.setup(ctx, code); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+
+	slog.Debug("plugin loaded",
+		slog.Group("plugin",
+			slog.String("name", p.name),
+			slog.Any("argTypes", p.argTypes),
+			slog.Any("kinds", p.kinds),
+		),
+	)
+
+	return p, nil
+}
+
+func (p *Plugin) setup(ctx context.Context, code []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return fmt.Errorf("failed to instantiate wasi: %w", err)
+	}
+
+	_, err := p.runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().WithFunc(p.hostLog).Export("log").
+		NewFunctionBuilder().WithFunc(p.hostHTTPRequest).Export("http_request").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+
+	if p.compiled, err = p.runtime.CompileModule(ctx, code); err != nil {
+		return fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	exports := p.compiled.ExportedFunctions()
+	required := []string{allocFunc}
+	if len(p.argTypes) > 0 {
+		required = append(required, resolveFunc)
+	}
+	if len(p.kinds) > 0 {
+		required = append(required, executeFunc)
+	}
+	for _, name := range required {
+		if _, ok := exports[name]; !ok {
+			return fmt.Errorf("module does not export %s", name)
+		}
+	}
+	if _, ok := p.compiled.ExportedMemories()["memory"]; !ok {
+		return errors.New("module does not export memory")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.instance(ctx)
+	return err
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// ArgTypes returns the arg types the plugin resolves.
+func (p *Plugin) ArgTypes() []string {
+	return p.argTypes
+}
+
+// Kinds returns the request kinds the plugin executes.
+func (p *Plugin) Kinds() []string {
+	return p.kinds
+}
+
+// Close releases the plugin runtime.
+func (p *Plugin) Close(ctx context.Context) error {
+	return p.runtime.Close(ctx)
+}
+
+// instance returns the module instance, instantiating it when it does not exist or was closed.
+// It must be called with mu held.
+func (p *Plugin) instance(ctx context.Context) (api.Module, error) {
+	if p.module != nil && !p.module.IsClosed() {
+		return p.module, nil
+	}
+
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStderr(os.Stderr),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+	p.module = module
+	return module, nil
+}
+
+// call writes the JSON encoded input to the module memory, calls the function and decodes its output.
+func (p *Plugin) call(ctx context.Context, function string, in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("plugin %s: failed to encode input: %w", p.name, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	module, err := p.instance(ctx)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+
+	ptr, err := writeInput(callCtx, module, data)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+
+	res, err := module.ExportedFunction(function).Call(callCtx, uint64(ptr), uint64(len(data)))
+	if err != nil {
+		if callCtx.Err() != nil {
+			return fmt.Errorf("plugin %s: %s: %w", p.name, function, callCtx.Err())
+		}
+		return fmt.Errorf("plugin %s: %s: %w", p.name, function, err)
+	}
+
+	output, err := readOutput(module, res[0])
+	if err != nil {
+		return fmt.Errorf("plugin %s: %s: %w", p.name, function, err)
+	}
+	if err = json.Unmarshal(output, out); err != nil {
+		return fmt.Errorf("plugin %s: %s: invalid output: %w", p.name, function, err)
+	}
+	return nil
+}
+
+// writeInput copies data into memory allocated by the module.
+func writeInput(ctx context.Context, module api.Module, data []byte) (uint32, error) {
+	res, err := module.ExportedFunction(allocFunc).Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", allocFunc, err)
+	}
+
+	ptr := uint32(res[0])
+	if !module.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("%s returned an out of range pointer", allocFunc)
+	}
+	return ptr, nil
+}
+
+// readOutput copies the data referenced by a packed ptr<<32 | len out of the module memory.
+func readOutput(module api.Module, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+	data, ok := module.Memory().Read(ptr, size)
+	if !ok {
+		return nil, errors.New("output out of range")
+	}
+	return append([]byte(nil), data...), nil
+}
@@ -0,0 +1,179 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/AdamShannag/api-mcp-server/pkg/plugin"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/resolver"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArgResolver(t *testing.T) {
+	path := writeModule(t, testModule{
+		funcs: map[string]body{"resolve": returnData(`{"value":"01ARZ3NDEKTSV4RRFFQ69G5FAV"}`)},
+	})
+
+	p := load(t, types.Plugin{Name: "ulid", Path: path, ArgTypes: []string{"ulid"}})
+
+	registry := resolver.NewDefaultTypeResolverRegistry()
+	registry.Register("ulid", p.ArgResolver())
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"id": "01arz3ndektsv4rrffq69g5fav"}
+
+	val, err := registry.Resolve(context.Background(), req, types.Arg{Name: "id", Type: "ulid", Required: true})
+	require.NoError(t, err)
+	assert.Equal(t, "01ARZ3NDEKTSV4RRFFQ69G5FAV", val)
+
+	tool := mcp.NewTool("Get", registry.ToToolOption(types.Arg{Name: "id", Type: "ulid"}))
+	assert.Equal(t, "string", tool.InputSchema.Properties["id"].(map[string]any)["type"])
+}
+
+func TestArgResolver_Error(t *testing.T) {
+	path := writeModule(t, testModule{
+		funcs: map[string]body{"resolve": returnData(`{"error":"invalid ulid"}`)},
+	})
+
+	p := load(t, types.Plugin{Name: "ulid", Path: path, ArgTypes: []string{"ulid"}})
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"id": "nope"}
+
+	_, err := p.ArgResolver().Resolve(context.Background(), req, types.Arg{Name: "id", Type: "ulid"})
+	assert.EqualError(t, err, "invalid ulid")
+}
+
+func TestExecutor(t *testing.T) {
+	path := writeModule(t, testModule{
+		funcs: map[string]body{"execute": returnData(`{"result":"{\"ok\":true}"}`)},
+	})
+
+	p := load(t, types.Plugin{Name: "soap", Path: path, Kinds: []string{"soap"}})
+	ex := request.NewExecutor(request.WithKindExecutor("soap", p.Executor()))
+
+	result, err := ex.Execute(context.Background(), types.Request{Kind: "soap"}, map[string]string{"id": "1"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, result)
+}
+
+func TestExecutor_ErrorResponse(t *testing.T) {
+	path := writeModule(t, testModule{
+		funcs: map[string]body{"execute": returnData(`{"error":"soap fault","errorResponse":{"status_code":500,"status":"Fault","body":"boom"}}`)},
+	})
+
+	p := load(t, types.Plugin{Name: "soap", Path: path, Kinds: []string{"soap"}})
+
+	_, err := p.Executor().Execute(context.Background(), types.Request{Kind: "soap"}, nil)
+
+	var statusErr *request.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, "soap fault", statusErr.Error())
+	assert.Equal(t, 500, statusErr.Response.StatusCode)
+	assert.Equal(t, "boom", statusErr.Response.Body)
+}
+
+func TestTimeout(t *testing.T) {
+	path := writeModule(t, testModule{
+		funcs: map[string]body{
+			"execute": loopForever(),
+			"resolve": returnData(`{"value":"ok"}`),
+		},
+	})
+
+	p := load(t, types.Plugin{Name: "slow", Path: path, Kinds: []string{"slow"}, ArgTypes: []string{"slow"}, Timeout: "50ms"})
+
+	_, err := p.Executor().Execute(context.Background(), types.Request{Kind: "slow"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deadline exceeded")
+
+	var req mcp.CallToolRequest
+	val, err := p.ArgResolver().Resolve(context.Background(), req, types.Arg{Name: "id"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", val)
+}
+
+func TestHTTPRequest(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = r.Method + " " + r.Header.Get("X-Test") + " " + string(body)
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer srv.Close()
+
+	input, _ := json.Marshal(map[string]any{
+		"method":  "POST",
+		"url":     srv.URL,
+		"headers": map[string]string{"X-Test": "yes"},
+		"body":    "ping",
+	})
+
+	// execute returns the http_request output as-is, so the host's error field surfaces as the execute error.
+	path := writeModule(t, testModule{
+		imports: []string{"http_request"},
+		funcs:   map[string]body{"execute": callImport(0, string(input))},
+	})
+
+	p := load(t, types.Plugin{Name: "net", Path: path, Kinds: []string{"net"}})
+	_, err := p.Executor().Execute(context.Background(), types.Request{Kind: "net"}, nil)
+	assert.EqualError(t, err, "http requests are not allowed for this plugin")
+	assert.Empty(t, received)
+
+	p = load(t, types.Plugin{Name: "net", Path: path, Kinds: []string{"net"}, AllowHTTP: true})
+	_, err = p.Executor().Execute(context.Background(), types.Request{Kind: "net"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "POST yes ping", received)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	valid := writeModule(t, testModule{funcs: map[string]body{"resolve": returnData(`{}`)}})
+
+	invalid := filepath.Join(t.TempDir(), "invalid.wasm")
+	require.NoError(t, os.WriteFile(invalid, []byte("not wasm"), 0644))
+
+	tests := []struct {
+		name string
+		cfg  types.Plugin
+		want string
+	}{
+		{name: "missing file", cfg: types.Plugin{Path: filepath.Join(t.TempDir(), "nope.wasm")}, want: "failed to read module"},
+		{name: "invalid module", cfg: types.Plugin{Path: invalid}, want: "failed to compile module"},
+		{name: "missing execute", cfg: types.Plugin{Path: valid, Kinds: []string{"x"}}, want: "does not export execute"},
+		{name: "invalid timeout", cfg: types.Plugin{Path: valid, Timeout: "soon"}, want: "invalid timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := plugin.Load(context.Background(), tt.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func load(t *testing.T, cfg types.Plugin) *plugin.Plugin {
+	t.Helper()
+
+	p, err := plugin.Load(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close(context.Background()) })
+	return p
+}
+
+func writeModule(t *testing.T, m testModule) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	require.NoError(t, os.WriteFile(path, m.encode(), 0644))
+	return path
+}
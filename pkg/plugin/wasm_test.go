@@ -0,0 +1,155 @@
+package plugin_test
+
+import (
+	"bytes"
+	"slices"
+)
+
+const (
+	dataOffset = 1024
+	heapStart  = 32 << 10
+)
+
+// testModule assembles a minimal plugin module exporting memory, a bump allocator and the given
+// functions, each of type (i32, i32) -> i64. Imports are resolved from the api_mcp host module.
+type testModule struct {
+	imports []string
+	funcs   map[string]body
+}
+
+// body builds the instructions of a function, placing its constant data in the data segment.
+type body func(data *bytes.Buffer) []byte
+
+func (m testModule) encode() []byte {
+	var data bytes.Buffer
+	names := make([]string, 0, len(m.funcs))
+	for name := range m.funcs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	funcTypes := []byte{0x00} // alloc
+	codes := [][]byte{code(0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b)}
+	for _, name := range names {
+		funcTypes = append(funcTypes, 0x01)
+		codes = append(codes, code(m.funcs[name](&data)...))
+	}
+
+	var out bytes.Buffer
+	out.Write([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00})
+
+	section(&out, 1, vec(
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e},
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},
+	))
+
+	var imports [][]byte
+	for _, name := range m.imports {
+		typ := byte(0x01)
+		if name == "log" {
+			typ = 0x02
+		}
+		imports = append(imports, concat(str("api_mcp"), str(name), []byte{0x00, typ}))
+	}
+	section(&out, 2, vec(imports...))
+
+	section(&out, 3, concat(uleb(uint64(len(funcTypes))), funcTypes))
+	section(&out, 5, vec([]byte{0x00, 0x01}))
+	section(&out, 6, vec(concat([]byte{0x7f, 0x01, 0x41}, sleb(heapStart), []byte{0x0b})))
+
+	numImports := len(m.imports)
+	exports := [][]byte{
+		concat(str("memory"), []byte{0x02, 0x00}),
+		concat(str("alloc"), []byte{0x00}, uleb(uint64(numImports))),
+	}
+	for i, name := range names {
+		exports = append(exports, concat(str(name), []byte{0x00}, uleb(uint64(numImports+1+i))))
+	}
+	section(&out, 7, vec(exports...))
+
+	section(&out, 10, vec(codes...))
+	section(&out, 11, vec(concat([]byte{0x00, 0x41}, sleb(dataOffset), []byte{0x0b}, uleb(uint64(data.Len())), data.Bytes())))
+
+	return out.Bytes()
+}
+
+// returnData returns a function returning s, packed as ptr<<32 | len.
+func returnData(s string) body {
+	return func(data *bytes.Buffer) []byte {
+		off := dataOffset + data.Len()
+		data.WriteString(s)
+		return concat([]byte{0x42}, sleb(int64(off)<<32|int64(len(s))), []byte{0x0b})
+	}
+}
+
+// loopForever returns a function that never returns.
+func loopForever() body {
+	return func(*bytes.Buffer) []byte {
+		return []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b}
+	}
+}
+
+// callImport returns a function calling the import at index idx with input and returning its result.
+func callImport(idx int, input string) body {
+	return func(data *bytes.Buffer) []byte {
+		off := dataOffset + data.Len()
+		data.WriteString(input)
+		return concat(
+			[]byte{0x41}, sleb(int64(off)),
+			[]byte{0x41}, sleb(int64(len(input))),
+			[]byte{0x10}, uleb(uint64(idx)),
+			[]byte{0x0b},
+		)
+	}
+}
+
+func code(instructions ...byte) []byte {
+	b := concat([]byte{0x00}, instructions)
+	return concat(uleb(uint64(len(b))), b)
+}
+
+func section(out *bytes.Buffer, id byte, payload []byte) {
+	out.WriteByte(id)
+	out.Write(uleb(uint64(len(payload))))
+	out.Write(payload)
+}
+
+func vec(items ...[]byte) []byte {
+	return concat(append([][]byte{uleb(uint64(len(items)))}, items...)...)
+}
+
+func str(s string) []byte {
+	return concat(uleb(uint64(len(s))), []byte(s))
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if v == 0 {
+			return out
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
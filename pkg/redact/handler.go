@@ -0,0 +1,73 @@
+package redact
+
+import (
+	"context"
+	"log/slog"
+)
+
+type handler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+// Handler wraps a slog.Handler, redacting the message and attributes of every record before passing it on.
+func (r *Redactor) Handler(next slog.Handler) slog.Handler {
+	return &handler{next: next, redactor: r}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record, and masks the keys of the tool called in ctx and the values marked as
+// sensitive in ctx, see WithTool and WithValues.
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	m := h.redactor.masker(ctx)
+
+	redacted := slog.NewRecord(record.Time, record.Level, m.str(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactor.attr(a, m))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactor.Attr(a)
+	}
+	return &handler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// Attr redacts a log attribute: the value of a sensitive key is masked, strings are redacted, and groups,
+// maps and slices are redacted recursively.
+func (r *Redactor) Attr(a slog.Attr) slog.Attr {
+	return r.attr(a, masker{str: r.String, key: r.Key})
+}
+
+func (r *Redactor) attr(a slog.Attr, m masker) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if m.key(a.Key) {
+		return slog.String(a.Key, Mask)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, m.str(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = r.attr(ga, m)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindAny:
+		return slog.Any(a.Key, r.value(a.Value.Any(), m))
+	}
+	return a
+}
@@ -0,0 +1,244 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+var (
+	// DefaultHeaders are header names always redacted.
+	DefaultHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Private-Token"}
+
+	// DefaultParams are query param and field names always redacted.
+	DefaultParams = []string{"access_token", "refresh_token", "client_secret", "api_key", "apikey", "password", "secret", "token"}
+
+	// DefaultPatterns match common credentials, which are redacted wherever they appear.
+	DefaultPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`),
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+		regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|glpat-[A-Za-z0-9_-]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`),
+		regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+)
+
+type Option func(*Redactor)
+
+// Redactor masks sensitive values in strings and log records: the values of sensitive keys, found as
+// attribute keys, key=value pairs and JSON fields, and the matches of the redaction patterns.
+// Keys are matched case-insensitively. It is safe for concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	keys     *keySet
+	tools    map[string]*keySet
+	patterns []*regexp.Regexp
+}
+
+// New creates a redactor for the default headers, params and patterns and those given by the options.
+func New(opts ...Option) *Redactor {
+	r := &Redactor{
+		tools:    make(map[string]*keySet),
+		patterns: slices.Clone(DefaultPatterns),
+	}
+	r.AddKeys(DefaultHeaders...)
+	r.AddKeys(DefaultParams...)
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WithHeaders redacts the values of headers with the given names.
+func WithHeaders(names ...string) Option {
+	return func(r *Redactor) {
+		r.AddKeys(names...)
+	}
+}
+
+// WithParams redacts the values of query params and fields with the given names.
+func WithParams(names ...string) Option {
+	return func(r *Redactor) {
+		r.AddKeys(names...)
+	}
+}
+
+// WithPatterns redacts every match of the patterns.
+func WithPatterns(patterns ...*regexp.Regexp) Option {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, patterns...)
+	}
+}
+
+// AddKeys marks keys as sensitive after construction, in the records of every call.
+func (r *Redactor) AddKeys(keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = r.keys.with(keys...)
+}
+
+// SetToolKeys sets the keys only sensitive in the records logged during calls of a tool, e.g. the names
+// of its sensitive args, replacing those set before. See WithTool.
+func (r *Redactor) SetToolKeys(tool string, keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ks := (*keySet)(nil).with(keys...); ks != nil {
+		r.tools[tool] = ks
+	} else {
+		delete(r.tools, tool)
+	}
+}
+
+// Key reports whether the values of key are redacted.
+func (r *Redactor) Key(key string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys.has(key)
+}
+
+// String returns s with the values of sensitive keys and the matches of the patterns masked.
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	keys := r.keys
+	r.mu.RUnlock()
+
+	s = keys.mask(s)
+	for _, p := range r.patterns {
+		s = p.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// KeyContext is Key, also reporting the keys of the tool called in ctx as redacted.
+func (r *Redactor) KeyContext(ctx context.Context, key string) bool {
+	return r.masker(ctx).key(key)
+}
+
+// StringContext is String, also masking the keys of the tool called in ctx and the values marked as
+// sensitive in ctx, see WithTool and WithValues.
+func (r *Redactor) StringContext(ctx context.Context, s string) string {
+	return r.masker(ctx).str(s)
+}
+
+// masker masks the strings and the values of sensitive keys of a record.
+type masker struct {
+	str func(string) string
+	key func(string) bool
+}
+
+func (r *Redactor) masker(ctx context.Context) masker {
+	m := masker{str: r.String, key: r.Key}
+
+	r.mu.RLock()
+	tool := r.tools[toolFrom(ctx)]
+	r.mu.RUnlock()
+	if tool != nil {
+		m.str = func(s string) string { return tool.mask(r.String(s)) }
+		m.key = func(key string) bool { return r.Key(key) || tool.has(key) }
+	}
+
+	if values := valuesFrom(ctx); len(values) > 0 {
+		str := m.str
+		m.str = func(s string) string { return maskValues(str(s), values) }
+	}
+	return m
+}
+
+// Args returns a copy of tool call args with the values of sensitive args masked, in nested objects too.
+func (r *Redactor) Args(args map[string]any) map[string]any {
+	return r.Value(args).(map[string]any)
+}
+
+// Value returns a copy of v with the values of sensitive keys masked in maps and slices, recursively, and
+// strings redacted. Other values are kept unless their string form holds something to mask.
+func (r *Redactor) Value(v any) any {
+	return r.value(v, masker{str: r.String, key: r.Key})
+}
+
+func (r *Redactor) value(v any, m masker) any {
+	switch v := v.(type) {
+	case string:
+		return m.str(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if m.key(k) {
+				out[k] = Mask
+				continue
+			}
+			out[k] = r.value(val, m)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = r.value(val, m)
+		}
+		return out
+	}
+
+	s := fmt.Sprint(v)
+	if redacted := m.str(s); redacted != s {
+		return redacted
+	}
+	return v
+}
+
+// keySet is an immutable set of sensitive keys, with the patterns matching their values in strings.
+type keySet struct {
+	keys   map[string]bool
+	pairs  *regexp.Regexp
+	fields *regexp.Regexp
+}
+
+// with returns a set holding the keys of ks and keys, or ks itself when it holds them all already.
+func (ks *keySet) with(keys ...string) *keySet {
+	all := make(map[string]bool)
+	if ks != nil {
+		maps.Copy(all, ks.keys)
+	}
+	n := len(all)
+	for _, key := range keys {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			all[key] = true
+		}
+	}
+	if len(all) == n {
+		return ks
+	}
+
+	quoted := make([]string, 0, len(all))
+	for key := range all {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	// Longer keys first, so "access_token" is preferred over "token".
+	slices.SortFunc(quoted, func(a, b string) int { return len(b) - len(a) })
+	alt := strings.Join(quoted, "|")
+
+	return &keySet{
+		keys:   all,
+		pairs:  regexp.MustCompile(`(?i)\b(` + alt + `)=[^&#\s"',;]*`),
+		fields: regexp.MustCompile(`(?i)"(` + alt + `)"(\s*:\s*)"(?:[^"\\]|\\.)*"`),
+	}
+}
+
+func (ks *keySet) has(key string) bool {
+	return ks != nil && ks.keys[strings.ToLower(key)]
+}
+
+func (ks *keySet) mask(s string) string {
+	if ks == nil {
+		return s
+	}
+	s = ks.pairs.ReplaceAllString(s, "${1}="+Mask)
+	return ks.fields.ReplaceAllString(s, `"${1}"${2}"`+Mask+`"`)
+}
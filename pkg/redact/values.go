@@ -0,0 +1,60 @@
+package redact
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// minValueLength is the length below which values are not masked, as they would mask unrelated text.
+const minValueLength = 4
+
+type (
+	valuesKey struct{}
+	toolKey   struct{}
+)
+
+// WithTool marks the records logged with the returned context as logged during a call of the tool, masking
+// the keys set for it with SetToolKeys.
+func WithTool(ctx context.Context, tool string) context.Context {
+	return context.WithValue(ctx, toolKey{}, tool)
+}
+
+func toolFrom(ctx context.Context) string {
+	tool, _ := ctx.Value(toolKey{}).(string)
+	return tool
+}
+
+// WithValues marks values as sensitive in the records logged with the returned context, e.g. the values of
+// the sensitive args of a tool call. The values are masked as they are and URL-escaped. Values shorter than
+// 4 bytes are not masked.
+func WithValues(ctx context.Context, values ...string) context.Context {
+	masked := slices.Clone(valuesFrom(ctx))
+	for _, v := range values {
+		if len(v) >= minValueLength {
+			masked = append(masked, v, url.PathEscape(v), url.QueryEscape(v))
+		}
+	}
+	// Longer values first, so a value is masked before the values it contains.
+	slices.SortFunc(masked, func(a, b string) int {
+		if n := len(b) - len(a); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	return context.WithValue(ctx, valuesKey{}, slices.Compact(masked))
+}
+
+func valuesFrom(ctx context.Context) []string {
+	values, _ := ctx.Value(valuesKey{}).([]string)
+	return values
+}
+
+// maskValues replaces every occurrence of the values in s.
+func maskValues(s string, values []string) string {
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package request
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+type omittedKey struct{}
+
+// WithOmittedArgs returns a context recording the optional args the caller did not pass. They still hold
+// zero values for the placeholders and params using them, but are left out of GraphQL variables and gRPC
+// messages, where a zero value differs from no value.
+func WithOmittedArgs(ctx context.Context, names ...string) context.Context {
+	omitted := make(map[string]bool, len(names))
+	for _, name := range names {
+		omitted[name] = true
+	}
+	return context.WithValue(ctx, omittedKey{}, omitted)
+}
+
+// Omitted reports whether the caller did not pass the optional arg.
+func Omitted(ctx context.Context, name string) bool {
+	omitted, _ := ctx.Value(omittedKey{}).(map[string]bool)
+	return omitted[name]
+}
+
+// OmittedArgs returns the args recorded by WithOmittedArgs, sorted.
+func OmittedArgs(ctx context.Context) []string {
+	omitted, _ := ctx.Value(omittedKey{}).(map[string]bool)
+	return slices.Sorted(maps.Keys(omitted))
+}
@@ -0,0 +1,85 @@
+package request
+
+import (
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"net/http"
+)
+
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+	AuthAPIKey = "apiKey"
+)
+
+// applyAuth adds the credentials of auth to the request, resolving their {{secret provider key}} placeholders.
+// Headers set explicitly on the request take precedence.
+func applyAuth(req *http.Request, auth *types.Auth, secretFunc placeholder.Func) error {
+	if auth == nil {
+		return nil
+	}
+
+	funcs := map[string]placeholder.Func{"secret": secretFunc}
+	credential := func(field, val string) (string, error) {
+		val, err := placeholder.Expand(val, funcs)
+		if err != nil {
+			return "", fmt.Errorf("invalid auth %s: %w", field, err)
+		}
+		return val, nil
+	}
+
+	setHeader := func(name, val string) error {
+		if err := validateHeaderValue(name, val); err != nil {
+			return err
+		}
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, val)
+		}
+		return nil
+	}
+
+	switch auth.Type {
+	case AuthBearer:
+		token, err := credential("token", auth.Token)
+		if err != nil {
+			return err
+		}
+		return setHeader("Authorization", "Bearer "+token)
+	case AuthBasic:
+		if req.Header.Get("Authorization") != "" {
+			return nil
+		}
+		username, err := credential("username", auth.Username)
+		if err != nil {
+			return err
+		}
+		password, err := credential("password", auth.Password)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(username, password)
+		return nil
+	case AuthAPIKey:
+		if auth.Name == "" {
+			return fmt.Errorf("%s auth requires a name", AuthAPIKey)
+		}
+		token, err := credential("token", auth.Token)
+		if err != nil {
+			return err
+		}
+		switch auth.In {
+		case "", "header":
+			return setHeader(auth.Name, token)
+		case "query":
+			query := req.URL.Query()
+			query.Set(auth.Name, token)
+			req.URL.RawQuery = query.Encode()
+			return nil
+		default:
+			return fmt.Errorf("unsupported %s auth location: %s", AuthAPIKey, auth.In)
+		}
+	default:
+		return fmt.Errorf("unsupported auth type: %s", auth.Type)
+	}
+}
@@ -0,0 +1,221 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	BodyTypeRaw       = ""
+	BodyTypeJSON      = "json"
+	BodyTypeForm      = "form"
+	BodyTypeMultipart = "multipart"
+
+	FileSourceBase64 = "base64"
+	FileSourcePath   = "path"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// buildRequestBody encodes the request body according to its body type and returns it with the
+// Content-Type to send. An empty Content-Type leaves the configured headers untouched.
+func (e *executor) buildRequestBody(ctx context.Context, request types.Request, args map[string]string) (io.Reader, string, error) {
+	if request.Kind == KindGraphQL {
+		return e.buildGraphQLBody(ctx, request, args)
+	}
+
+	switch request.BodyType {
+	case BodyTypeRaw:
+		body := args[request.Body]
+		if body == "" {
+			return nil, "", nil
+		}
+		return strings.NewReader(body), "", nil
+
+	case BodyTypeJSON:
+		return e.buildJSONBody(request, args)
+
+	case BodyTypeForm:
+		form := url.Values{}
+		for _, name := range request.BodyParams {
+			if val, ok := args[name]; ok {
+				form.Set(name, val)
+			}
+		}
+		return strings.NewReader(form.Encode()), "application/x-www-form-urlencoded", nil
+
+	case BodyTypeMultipart:
+		return e.buildMultipartBody(request, args)
+
+	default:
+		return nil, "", fmt.Errorf("unsupported body type: %s", request.BodyType)
+	}
+}
+
+// buildJSONBody sends the raw body arg when set, otherwise an object built from the body params.
+func (e *executor) buildJSONBody(request types.Request, args map[string]string) (io.Reader, string, error) {
+	const contentType = "application/json"
+
+	if request.Body != "" {
+		body := args[request.Body]
+		if body == "" {
+			return nil, contentType, nil
+		}
+		if !json.Valid([]byte(body)) {
+			return nil, "", fmt.Errorf("body %q is not valid JSON", request.Body)
+		}
+		return strings.NewReader(body), contentType, nil
+	}
+
+	obj := make(map[string]any, len(request.BodyParams))
+	for _, name := range request.BodyParams {
+		if val, ok := args[name]; ok {
+			obj[name] = TypedValue(request.ArgTypes[name], val)
+		}
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode JSON body: %w", err)
+	}
+	return bytes.NewReader(data), contentType, nil
+}
+
+func (e *executor) buildMultipartBody(request types.Request, args map[string]string) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for _, name := range request.BodyParams {
+		if val, ok := args[name]; ok {
+			if err := w.WriteField(name, val); err != nil {
+				return nil, "", fmt.Errorf("failed to write form field %q: %w", name, err)
+			}
+		}
+	}
+
+	for _, file := range request.Files {
+		val := args[file.Arg]
+		if val == "" {
+			continue
+		}
+		if err := e.writeFilePart(w, file, val); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+func (e *executor) writeFilePart(w *multipart.Writer, file types.FilePart, val string) error {
+	var content io.Reader
+	fileName := file.FileName
+
+	switch file.Source {
+	case FileSourceBase64:
+		data, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return fmt.Errorf("file %q: invalid base64 content: %w", file.Field, err)
+		}
+		content = bytes.NewReader(data)
+
+	case FileSourcePath:
+		f, err := e.openUpload(val)
+		if err != nil {
+			return fmt.Errorf("file %q: %w", file.Field, err)
+		}
+		defer func() { _ = f.Close() }()
+		content = f
+		if fileName == "" {
+			fileName = filepath.Base(f.Name())
+		}
+
+	default:
+		return fmt.Errorf("file %q: unsupported source: %s", file.Field, file.Source)
+	}
+
+	if fileName == "" {
+		fileName = file.Field
+	}
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(file.Field), quoteEscaper.Replace(fileName)))
+	header.Set("Content-Type", contentType)
+
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("file %q: failed to create part: %w", file.Field, err)
+	}
+	if _, err = io.Copy(part, content); err != nil {
+		return fmt.Errorf("file %q: failed to write part: %w", file.Field, err)
+	}
+	return nil
+}
+
+// openUpload opens a local file only if it resolves, after following symlinks, inside an allowed upload directory.
+func (e *executor) openUpload(path string) (*os.File, error) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	for _, dir := range e.uploadDirs {
+		allowed, err := resolvePath(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(allowed, resolved)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return os.Open(resolved)
+	}
+
+	return nil, fmt.Errorf("path %q is outside the allowed upload directories", path)
+}
+
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// TypedValue converts a resolved arg value back to its JSON type according to the arg type.
+func TypedValue(argType, val string) any {
+	switch argType {
+	case "int":
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return n
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return val
+}
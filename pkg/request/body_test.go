@@ -0,0 +1,133 @@
+package request_test
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_FormBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:     http.MethodPost,
+		Host:       ts.URL[len("http://"):],
+		Endpoint:   "/oauth/token",
+		BodyType:   request.BodyTypeForm,
+		BodyParams: []string{"grant_type", "scope"},
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{
+		"grant_type": "client_credentials",
+		"scope":      "read write",
+	})
+	assert.NoError(t, err)
+}
+
+func TestExecute_JSONBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"title":"Bug"}`, string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	host := ts.URL[len("http://"):]
+
+	for _, req := range []types.Request{
+		{Method: http.MethodPost, Host: host, Endpoint: "/", BodyType: request.BodyTypeJSON, Body: "payload"},
+		{Method: http.MethodPost, Host: host, Endpoint: "/", BodyType: request.BodyTypeJSON, BodyParams: []string{"title"}},
+	} {
+		_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{
+			"payload": `{"title":"Bug"}`,
+			"title":   "Bug",
+		})
+		assert.NoError(t, err)
+	}
+
+	invalid := types.Request{Method: http.MethodPost, Host: host, Endpoint: "/", BodyType: request.BodyTypeJSON, Body: "payload"}
+	_, err := request.NewExecutor().Execute(context.Background(), invalid, map[string]string{"payload": "{oops"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not valid JSON")
+}
+
+func TestExecute_MultipartBody(t *testing.T) {
+	uploadDir := t.TempDir()
+	uploadFile := filepath.Join(uploadDir, "report.txt")
+	assert.NoError(t, os.WriteFile(uploadFile, []byte("from disk"), 0644))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "release notes", r.FormValue("description"))
+
+		inline, inlineHeader, err := r.FormFile("inline")
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(inline)
+		assert.Equal(t, "hello", string(data))
+		assert.Equal(t, "hello.txt", inlineHeader.Filename)
+		assert.Equal(t, "text/plain", inlineHeader.Header.Get("Content-Type"))
+
+		disk, diskHeader, err := r.FormFile("attachment")
+		assert.NoError(t, err)
+		data, _ = io.ReadAll(disk)
+		assert.Equal(t, "from disk", string(data))
+		assert.Equal(t, "report.txt", diskHeader.Filename)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:     http.MethodPost,
+		Host:       ts.URL[len("http://"):],
+		Endpoint:   "/uploads",
+		BodyType:   request.BodyTypeMultipart,
+		BodyParams: []string{"description"},
+		Files: []types.FilePart{
+			{Field: "inline", Arg: "content", Source: request.FileSourceBase64, FileName: "hello.txt", ContentType: "text/plain"},
+			{Field: "attachment", Arg: "path", Source: request.FileSourcePath},
+		},
+	}
+
+	ex := request.NewExecutor(request.WithUploadDirs(uploadDir))
+	_, err := ex.Execute(context.Background(), req, map[string]string{
+		"description": "release notes",
+		"content":     base64.StdEncoding.EncodeToString([]byte("hello")),
+		"path":        uploadFile,
+	})
+	assert.NoError(t, err)
+}
+
+func TestExecute_MultipartPathOutsideUploadDirs(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	assert.NoError(t, os.WriteFile(outside, []byte("secret"), 0644))
+
+	req := types.Request{
+		Method:   http.MethodPost,
+		Host:     "example.com",
+		Endpoint: "/uploads",
+		BodyType: request.BodyTypeMultipart,
+		Files:    []types.FilePart{{Field: "file", Arg: "path", Source: request.FileSourcePath}},
+	}
+
+	ex := request.NewExecutor(request.WithUploadDirs(t.TempDir()))
+	_, err := ex.Execute(context.Background(), req, map[string]string{"path": outside})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the allowed upload directories")
+}
@@ -0,0 +1,33 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/breaker"
+	"github.com/AdamShannag/api-mcp-server/pkg/bulkhead"
+	"net/http"
+)
+
+// send sends the request, with retries, through the circuit breaker of host. Transport errors and server
+// errors count as failures. Calls canceled or rejected by the bulkhead are not counted.
+func (e *executor) send(ctx context.Context, host string, policy retryPolicy, req *http.Request, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	if e.breaker == nil {
+		return e.do(ctx, policy, req, newRequest)
+	}
+
+	done, err := e.breaker.Allow(host)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.do(ctx, policy, req, newRequest)
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, bulkhead.ErrFull):
+		done(breaker.Ignored)
+	case err != nil, resp.StatusCode >= http.StatusInternalServerError:
+		done(breaker.Failure)
+	default:
+		done(breaker.Success)
+	}
+	return resp, err
+}
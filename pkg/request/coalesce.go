@@ -0,0 +1,102 @@
+package request
+
+import (
+	"bytes"
+	"github.com/AdamShannag/api-mcp-server/pkg/cache"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"net/http"
+)
+
+// maxCoalescedBodySize bounds the response bodies buffered to be shared. Larger responses are streamed to
+// the request that fetched them, and the requests waiting on it make their own round trips.
+const maxCoalescedBodySize = 8 << 20
+
+// coalescer shares one round trip between identical concurrent GET and HEAD requests.
+type coalescer struct {
+	next      http.RoundTripper
+	group     singleflight.Group
+	coalesced func(host string)
+}
+
+type sharedResponse struct {
+	resp *http.Response
+	body []byte
+
+	// tooLarge is set when the body exceeds maxCoalescedBodySize, and body only holds its start.
+	tooLarge bool
+	// canceled is set when the round trip failed as the context of the request making it was done.
+	canceled bool
+}
+
+func (c *coalescer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || (req.Body != nil && req.Body != http.NoBody) {
+		return c.next.RoundTrip(req)
+	}
+
+	leader := false
+	v, err, shared := c.group.Do(cache.Key(req), func() (any, error) {
+		leader = true
+		resp, err := c.next.RoundTrip(req)
+		if err != nil {
+			return &sharedResponse{canceled: req.Context().Err() != nil}, err
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxCoalescedBodySize+1))
+		if err != nil {
+			_ = resp.Body.Close()
+			return &sharedResponse{canceled: req.Context().Err() != nil}, err
+		}
+		if len(body) > maxCoalescedBodySize {
+			return &sharedResponse{resp: resp, body: body, tooLarge: true}, nil
+		}
+		_ = resp.Body.Close()
+		return &sharedResponse{resp: resp, body: body}, nil
+	})
+	s, _ := v.(*sharedResponse)
+
+	if leader {
+		if err != nil {
+			return nil, err
+		}
+		if s.tooLarge {
+			resp := *s.resp
+			resp.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(s.body), s.resp.Body), Closer: s.resp.Body}
+			return &resp, nil
+		}
+	} else if shared {
+		// The round trip is not shared when it ended with the context of the leader, while this one is still
+		// live, or when its response is too large to be buffered.
+		if (err != nil && s != nil && s.canceled && req.Context().Err() == nil) || (err == nil && s.tooLarge) {
+			return c.next.RoundTrip(req)
+		}
+		if c.coalesced != nil {
+			c.coalesced(req.URL.Host)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := *s.resp
+	resp.Header = s.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(s.body))
+	resp.ContentLength = int64(len(s.body))
+	resp.Request = req
+	return &resp, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// WithCoalescing shares one upstream round trip between identical concurrent GET and HEAD requests, with
+// the same URL and headers. coalesced, when not nil, is called for each request served by another's
+// round trip.
+func WithCoalescing(coalesced func(host string)) Option {
+	return func(e *executor) {
+		e.coalesce = true
+		e.coalesced = coalesced
+	}
+}
@@ -0,0 +1,129 @@
+package request
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"sync"
+	"time"
+)
+
+var ErrContinuationNotFound = errors.New("continuation not found or expired")
+
+// DefaultContinuationsMaxSize bounds the bytes held by a continuation store.
+const DefaultContinuationsMaxSize = 64 << 20
+
+// Continuations is a short-lived in-memory store holding the remainder of truncated responses,
+// handed out chunk by chunk through continuation tokens.
+type Continuations struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int64
+	size    int64
+	seq     uint64
+	entries map[string]continuation
+}
+
+type continuation struct {
+	statusCode int
+	data       []byte
+	chunkSize  int64
+	dropped    bool
+	expiresAt  time.Time
+	seq        uint64
+}
+
+// NewContinuations returns a store keeping entries for ttl. When holding more than maxSize bytes, the entries
+// stored first are evicted first. A maxSize of zero or less means DefaultContinuationsMaxSize.
+func NewContinuations(ttl time.Duration, maxSize int64) *Continuations {
+	if maxSize <= 0 {
+		maxSize = DefaultContinuationsMaxSize
+	}
+	return &Continuations{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[string]continuation),
+	}
+}
+
+// Put stores the remaining response data and returns the token used to fetch its first chunk.
+// dropped reports whether data beyond what is stored was discarded.
+func (c *Continuations) Put(statusCode int, data []byte, chunkSize int64, dropped bool) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for token, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			c.remove(token)
+		}
+	}
+	for c.size+int64(len(data)) > c.maxSize && len(c.entries) > 0 {
+		c.remove(c.oldest())
+	}
+
+	token := newToken()
+	c.seq++
+	c.entries[token] = continuation{
+		statusCode: statusCode,
+		data:       data,
+		chunkSize:  chunkSize,
+		dropped:    dropped,
+		expiresAt:  now.Add(c.ttl),
+		seq:        c.seq,
+	}
+	c.size += int64(len(data))
+	return token
+}
+
+// Next consumes the given token and returns the next chunk, along with a new token if more data remains.
+func (c *Continuations) Next(token string) (types.Response, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[token]
+	c.remove(token)
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return types.Response{}, ErrContinuationNotFound
+	}
+
+	response := types.Response{StatusCode: entry.statusCode}
+	if int64(len(entry.data)) <= entry.chunkSize {
+		response.Body = string(entry.data)
+		response.Truncated = entry.dropped
+		return response, nil
+	}
+
+	cut := runeCut(entry.data[:entry.chunkSize])
+	response.Body = string(entry.data[:cut])
+	response.Truncated = true
+	response.Continuation = c.Put(entry.statusCode, entry.data[cut:], entry.chunkSize, entry.dropped)
+	return response, nil
+}
+
+func (c *Continuations) remove(token string) {
+	if entry, ok := c.entries[token]; ok {
+		c.size -= int64(len(entry.data))
+		delete(c.entries, token)
+	}
+}
+
+func (c *Continuations) oldest() string {
+	var (
+		oldest string
+		seq    uint64
+	)
+	for token, entry := range c.entries {
+		if oldest == "" || entry.seq < seq {
+			oldest, seq = token, entry.seq
+		}
+	}
+	return oldest
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+)
+
+// StatusError is returned by Execute when the upstream reports a failure, either through a non-2xx
+// status code or through an error payload such as GraphQL errors.
+type StatusError struct {
+	Message  string
+	Response types.ErrorResponse
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("http request failed: %s", e.Response.Status)
+}
@@ -4,12 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/breaker"
+	"github.com/AdamShannag/api-mcp-server/pkg/bulkhead"
+	"github.com/AdamShannag/api-mcp-server/pkg/cache"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
 	"github.com/AdamShannag/api-mcp-server/pkg/types"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type Option func(*executor)
@@ -18,8 +29,30 @@ type Executor interface {
 	Execute(context.Context, types.Request, map[string]string) (string, error)
 }
 
+// KindRegistry is implemented by executors accepting executors for additional request kinds.
+type KindRegistry interface {
+	RegisterKind(kind string, delegate Executor)
+}
+
+// maxContinuationChunks bounds how many chunks of a truncated response are kept for continuation.
+const maxContinuationChunks = 64
+
 type executor struct {
-	httpClient *http.Client
+	httpClient      *http.Client
+	maxResponseSize int64
+	timeout         time.Duration
+	continuations   *Continuations
+	uploadDirs      []string
+	kinds           map[string]Executor
+	secrets         *secret.Store
+	redactor        *redact.Redactor
+	breaker         *breaker.Breaker
+	cache           *cache.Cache
+	bulkhead        *bulkhead.Bulkhead
+	coalesce        bool
+	coalesced       func(host string)
+	readOnly        bool
+	dryRun          bool
 }
 
 func NewExecutor(opts ...Option) Executor {
@@ -31,35 +64,127 @@ func NewExecutor(opts ...Option) Executor {
 		opt(e)
 	}
 
+	if e.bulkhead != nil || e.coalesce || e.cache != nil {
+		client := *e.httpClient
+		if client.Transport == nil {
+			client.Transport = http.DefaultTransport
+		}
+		if e.bulkhead != nil {
+			client.Transport = e.bulkhead.Transport(client.Transport)
+		}
+		if e.coalesce {
+			client.Transport = &coalescer{next: client.Transport, coalesced: e.coalesced}
+		}
+		if e.cache != nil {
+			client.Transport = e.cache.Transport(client.Transport)
+		}
+		e.httpClient = &client
+	}
+
 	return e
 }
 
 func (e *executor) Execute(ctx context.Context, request types.Request, argValues map[string]string) (string, error) {
+	request.Kind = NormalizeKind(request.Kind)
+	timeout := e.timeout
+	if request.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(request.Timeout); err != nil {
+			return "", fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if request.Cache != nil && e.cache != nil {
+		ttl := cache.DefaultTTL
+		if request.Cache.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(request.Cache.TTL); err != nil {
+				return "", fmt.Errorf("invalid cache ttl: %w", err)
+			}
+		}
+		ctx = cache.WithTTL(ctx, ttl)
+	}
+
+	if e.readOnly && !ReadOnly(request) {
+		return "", fmt.Errorf("%w: %s", ErrReadOnly, operation(request))
+	}
+
+	if kindExecutor, ok := e.kinds[request.Kind]; ok {
+		if e.dryRun {
+			return e.dryRunKind(request)
+		}
+		return kindExecutor.Execute(ctx, request, argValues)
+	}
+	if request.Kind != KindHTTP && request.Kind != KindGraphQL {
+		return "", fmt.Errorf("unsupported request kind: %s", request.Kind)
+	}
+
 	endpoint, err := e.buildEndpoint(request, argValues)
 	if err != nil {
 		return "", err
 	}
 
 	fullURL := e.buildFullURL(request.Secure, request.Host, endpoint)
-	body := e.buildRequestBody(argValues[request.Body])
 
-	req, err := http.NewRequestWithContext(ctx, request.Method, fullURL, body)
+	method := request.Method
+	if method == "" && request.Kind == KindGraphQL {
+		method = http.MethodPost
+	}
+
+	// The secrets resolved into the headers are masked in the logs of the call.
+	var secrets []string
+	secretFunc := secret.Record(e.secrets.Func(ctx), &secrets)
+	newRequest := func() (*http.Request, error) {
+		body, contentType, err := e.buildRequestBody(ctx, request, argValues)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header, err = e.buildHeaders(request, argValues, secretFunc)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if err = applyAuth(req, request.Auth, secretFunc); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+
+	policy, err := newRetryPolicy(request.Retry)
 	if err != nil {
 		return "", err
 	}
 
-	for k, v := range request.Headers {
-		req.Header.Set(k, v)
+	req, err := newRequest()
+	if err != nil {
+		return "", err
+	}
+	ctx = redact.WithValues(ctx, secrets...)
+	if e.dryRun {
+		return e.dryRunResult(request, req)
 	}
 
-	slog.Info("executing http request",
+	slog.InfoContext(ctx, "executing http request",
 		slog.Group("request",
-			slog.String("method", request.Method),
+			slog.String("method", method),
 			slog.String("url", fullURL),
 		),
 	)
 
-	resp, err := e.httpClient.Do(req)
+	resp, err := e.send(ctx, request.Host, policy, req, newRequest)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -69,25 +194,60 @@ func (e *executor) Execute(ctx context.Context, request types.Request, argValues
 		}
 	}()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	limit := e.maxResponseSize
+	if request.MaxResponseSize > 0 {
+		limit = request.MaxResponseSize
+	}
+
+	bodyBytes, rest, dropped, err := e.readBody(resp.Body, limit)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode >= 400 {
-		slog.Error("http request failed",
+	headers := e.selectHeaders(resp.Header, request.ResponseHeaders)
+	truncated := len(rest) > 0 || dropped
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slog.ErrorContext(ctx, "http request failed",
 			slog.Group("request",
-				slog.String("method", request.Method),
+				slog.String("method", method),
 				slog.String("url", fullURL),
 				slog.String("response", string(bodyBytes)),
 			),
 		)
-		return "", fmt.Errorf("http request failed: %s", bodyBytes)
+		return "", &StatusError{Response: types.ErrorResponse{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Headers:    headers,
+			Body:       e.parseBody(bodyBytes),
+			Hint:       e.statusHint(request.StatusHints, resp.StatusCode),
+		}}
+	}
+
+	if request.Kind == KindGraphQL && !truncated {
+		if gqlErrors, ok := e.graphQLErrors(bodyBytes); ok {
+			return "", &StatusError{
+				Message: "graphql request failed",
+				Response: types.ErrorResponse{
+					StatusCode: resp.StatusCode,
+					Status:     resp.Status,
+					Headers:    headers,
+					Body:       map[string]any{"errors": gqlErrors},
+					Hint:       request.StatusHints["graphql"],
+				},
+			}
+		}
 	}
 
 	response := types.Response{
 		StatusCode: resp.StatusCode,
 		Body:       string(bodyBytes),
+		Truncated:  truncated,
+		Headers:    headers,
+	}
+
+	if len(rest) > 0 && e.continuations != nil {
+		response.Continuation = e.continuations.Put(resp.StatusCode, rest, limit, dropped)
 	}
 
 	result, err := json.Marshal(response)
@@ -104,6 +264,82 @@ func WithHttpClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithMaxResponseSize sets the default maximum number of response body bytes returned per call.
+// A request's own MaxResponseSize takes precedence. Zero means unlimited.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *executor) {
+		c.maxResponseSize = size
+	}
+}
+
+// WithTimeout sets the default timeout of calls whose request sets none. Zero means no timeout. A timeout
+// of the http client also bounds calls, including those with a longer request timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *executor) {
+		c.timeout = timeout
+	}
+}
+
+// WithContinuations enables fetching the remainder of truncated responses through the given store.
+func WithContinuations(continuations *Continuations) Option {
+	return func(c *executor) {
+		c.continuations = continuations
+	}
+}
+
+// WithUploadDirs allows multipart file parts to be read from local paths inside the given directories.
+func WithUploadDirs(dirs ...string) Option {
+	return func(c *executor) {
+		c.uploadDirs = append(c.uploadDirs, dirs...)
+	}
+}
+
+// WithSecrets resolves {{secret <provider> <key>}} placeholders in headers and auth credentials from the store.
+func WithSecrets(store *secret.Store) Option {
+	return func(c *executor) {
+		c.secrets = store
+	}
+}
+
+// WithCache caches the responses of requests enabling it, see types.Cache. It wraps the transport of the
+// http client, so the option order does not matter.
+func WithCache(c *cache.Cache) Option {
+	return func(e *executor) {
+		e.cache = c
+	}
+}
+
+// WithBreaker fails requests fast while the circuit of their host is open.
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(e *executor) {
+		e.breaker = b
+	}
+}
+
+// WithBulkhead bounds the requests in flight, see bulkhead.Bulkhead. Cached and coalesced responses take no
+// slot, so the option order does not matter.
+func WithBulkhead(b *bulkhead.Bulkhead) Option {
+	return func(e *executor) {
+		e.bulkhead = b
+	}
+}
+
+// WithKindExecutor delegates requests of the given kind to another executor.
+func WithKindExecutor(kind string, delegate Executor) Option {
+	return func(c *executor) {
+		c.RegisterKind(kind, delegate)
+	}
+}
+
+// RegisterKind delegates requests of the given kind to another executor after construction.
+// It must not be called while requests are executed.
+func (e *executor) RegisterKind(kind string, delegate Executor) {
+	if e.kinds == nil {
+		e.kinds = make(map[string]Executor)
+	}
+	e.kinds[kind] = delegate
+}
+
 func (e *executor) buildEndpoint(request types.Request, args map[string]string) (string, error) {
 	endpoint := request.Endpoint
 
@@ -112,6 +348,9 @@ func (e *executor) buildEndpoint(request types.Request, args map[string]string)
 		if !ok {
 			return "", fmt.Errorf("missing required path param: %s", param)
 		}
+		if err := validatePathParam(param, val); err != nil {
+			return "", err
+		}
 		endpoint = strings.ReplaceAll(endpoint, ":"+param, url.PathEscape(val))
 	}
 
@@ -133,6 +372,65 @@ func (e *executor) buildEndpoint(request types.Request, args map[string]string)
 	return endpoint, nil
 }
 
+// buildHeaders resolves the static headers, expanding {{arg name}} and {{secret provider key}} placeholders,
+// and appends the header params. Headers that resolve to an empty value are omitted.
+func (e *executor) buildHeaders(request types.Request, args map[string]string, secretFunc placeholder.Func) (http.Header, error) {
+	funcs := map[string]placeholder.Func{
+		"arg": func(name string) (string, error) {
+			val, ok := args[name]
+			if !ok {
+				return "", fmt.Errorf("unknown arg: %s", name)
+			}
+			return val, nil
+		},
+		"secret": secretFunc,
+	}
+
+	headers := make(http.Header, len(request.Headers)+len(request.HeaderParams))
+	for k, v := range request.Headers {
+		val, err := placeholder.Expand(v, funcs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %w", k, err)
+		}
+		if err = validateHeaderValue(k, val); err != nil {
+			return nil, err
+		}
+		if val != "" {
+			headers.Set(k, val)
+		}
+	}
+
+	for _, name := range request.HeaderParams {
+		val := args[name]
+		if err := validateHeaderValue(name, val); err != nil {
+			return nil, err
+		}
+		if val != "" {
+			headers.Set(name, val)
+		}
+	}
+
+	return headers, nil
+}
+
+// validatePathParam rejects values holding dot segments, which servers decoding the escaped value could
+// resolve outside the endpoint. Other characters, like "/" and "?", are escaped and cannot change the URL.
+func validatePathParam(name, val string) error {
+	for _, segment := range strings.FieldsFunc(val, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("invalid path param %q: value must not contain . or .. segments", name)
+		}
+	}
+	return nil
+}
+
+func validateHeaderValue(name, val string) error {
+	if strings.ContainsAny(val, "\r\n\x00") {
+		return fmt.Errorf("invalid header %q: value must not contain CR, LF or NUL characters", name)
+	}
+	return nil
+}
+
 func (e *executor) buildFullURL(secure bool, host, endpoint string) string {
 	scheme := "http"
 	if secure {
@@ -141,9 +439,95 @@ func (e *executor) buildFullURL(secure bool, host, endpoint string) string {
 	return fmt.Sprintf("%s://%s%s", scheme, host, endpoint)
 }
 
-func (e *executor) buildRequestBody(body string) io.Reader {
-	if body == "" {
+// readBody reads up to limit bytes of the body. When the body is larger, the remainder is returned
+// separately if continuations are enabled, bounded to maxContinuationChunks chunks. dropped reports
+// whether any data was discarded.
+func (e *executor) readBody(body io.Reader, limit int64) ([]byte, []byte, bool, error) {
+	if limit <= 0 {
+		data, err := io.ReadAll(body)
+		return data, nil, false, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, limit))
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if e.continuations == nil {
+		extra, err := io.ReadAll(io.LimitReader(body, 1))
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if len(extra) == 0 {
+			return data, nil, false, nil
+		}
+		return data[:runeCut(data)], nil, true, nil
+	}
+
+	// The limit saturates, so that neither the product nor restLimit+1 overflows.
+	restLimit := int64(math.MaxInt64 - 1)
+	if limit < restLimit/maxContinuationChunks {
+		restLimit = limit * maxContinuationChunks
+	}
+	rest, err := io.ReadAll(io.LimitReader(body, restLimit+1))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(rest) == 0 {
+		return data, nil, false, nil
+	}
+
+	dropped := int64(len(rest)) > restLimit
+	if dropped {
+		rest = rest[:runeCut(rest[:restLimit])]
+	}
+	// A rune split by the limit is moved to the remainder, so the chunks join back into the body.
+	if cut := runeCut(data); cut < len(data) {
+		rest = append(slices.Clone(data[cut:]), rest...)
+		data = data[:cut]
+	}
+	return data, rest, dropped, nil
+}
+
+// runeCut returns the length of the longest prefix of b that does not end within a UTF-8 encoded rune.
+func runeCut(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if i > 0 && !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
+
+func (e *executor) selectHeaders(header http.Header, names []string) map[string]string {
+	if len(names) == 0 {
 		return nil
 	}
-	return strings.NewReader(body)
+	headers := make(map[string]string, len(names))
+	for _, name := range names {
+		if val := header.Get(name); val != "" {
+			headers[name] = val
+		}
+	}
+	return headers
+}
+
+// parseBody returns the body decoded as JSON when possible, otherwise as a plain string.
+func (e *executor) parseBody(body []byte) any {
+	var parsed any
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		return parsed
+	}
+	return string(body)
+}
+
+// statusHint looks up a hint by exact status code (e.g. "404"), then by class (e.g. "4xx").
+func (e *executor) statusHint(hints map[string]string, statusCode int) string {
+	if hint, ok := hints[strconv.Itoa(statusCode)]; ok {
+		return hint
+	}
+	return hints[fmt.Sprintf("%dxx", statusCode/100)]
 }
@@ -1,15 +1,29 @@
 package request_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/breaker"
+	"github.com/AdamShannag/api-mcp-server/pkg/bulkhead"
+	"github.com/AdamShannag/api-mcp-server/pkg/cache"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
 	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
 	"github.com/AdamShannag/api-mcp-server/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestExecute_Success(t *testing.T) {
@@ -60,6 +74,40 @@ func TestExecute_MissingPathParam(t *testing.T) {
 	assert.Contains(t, err.Error(), "missing required path param")
 }
 
+func TestExecute_PathParamTraversal(t *testing.T) {
+	req := types.Request{
+		Method:     http.MethodGet,
+		Host:       "example.com",
+		Endpoint:   "/users/:id/profile",
+		PathParams: []string{"id"},
+	}
+
+	ex := request.NewExecutor()
+	for _, val := range []string{"..", ".", "../admin", "a/../../admin", `..\admin`} {
+		_, err := ex.Execute(context.Background(), req, map[string]string{"id": val})
+		assert.ErrorContains(t, err, `invalid path param "id"`, val)
+	}
+}
+
+func TestExecute_PathParamEscaped(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/1%3Fadmin=true%23x/profile", r.URL.EscapedPath())
+		assert.Empty(t, r.URL.RawQuery)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:     http.MethodGet,
+		Host:       ts.URL[len("http://"):],
+		Endpoint:   "/users/:id/profile",
+		PathParams: []string{"id"},
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{"id": "1?admin=true#x"})
+	assert.NoError(t, err)
+}
+
 func TestExecute_ReadBodyFailure(t *testing.T) {
 	req := types.Request{
 		Method:   http.MethodGet,
@@ -85,6 +133,500 @@ func TestExecute_ReadBodyFailure(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to read response body")
 }
 
+func TestExecute_TruncatesWithContinuation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789abcdef"))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:          http.MethodGet,
+		Host:            ts.URL[len("http://"):],
+		Endpoint:        "/big",
+		MaxResponseSize: 6,
+	}
+
+	continuations := request.NewContinuations(time.Minute, 0)
+	ex := request.NewExecutor(request.WithContinuations(continuations))
+
+	result, err := ex.Execute(context.Background(), req, map[string]string{})
+	assert.NoError(t, err)
+
+	var resp types.Response
+	assert.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.Equal(t, "012345", resp.Body)
+	assert.True(t, resp.Truncated)
+	assert.NotEmpty(t, resp.Continuation)
+
+	next, err := continuations.Next(resp.Continuation)
+	assert.NoError(t, err)
+	assert.Equal(t, "6789ab", next.Body)
+	assert.True(t, next.Truncated)
+
+	last, err := continuations.Next(next.Continuation)
+	assert.NoError(t, err)
+	assert.Equal(t, "cdef", last.Body)
+	assert.False(t, last.Truncated)
+	assert.Empty(t, last.Continuation)
+
+	_, err = continuations.Next(next.Continuation)
+	assert.ErrorIs(t, err, request.ErrContinuationNotFound)
+}
+
+func TestExecute_ContinuationSplitsOnRunes(t *testing.T) {
+	body := "aé€b😀cd"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:          http.MethodGet,
+		Host:            ts.URL[len("http://"):],
+		Endpoint:        "/big",
+		MaxResponseSize: 4,
+	}
+
+	continuations := request.NewContinuations(time.Minute, 0)
+	ex := request.NewExecutor(request.WithContinuations(continuations))
+
+	result, err := ex.Execute(context.Background(), req, map[string]string{})
+	require.NoError(t, err)
+
+	var resp types.Response
+	require.NoError(t, json.Unmarshal([]byte(result), &resp))
+	joined := resp.Body
+	for token := resp.Continuation; token != ""; {
+		next, err := continuations.Next(token)
+		require.NoError(t, err)
+		assert.NotContains(t, next.Body, "\uFFFD")
+		joined += next.Body
+		token = next.Continuation
+	}
+	assert.Equal(t, body, joined)
+}
+
+func TestContinuations_MaxSize(t *testing.T) {
+	continuations := request.NewContinuations(time.Minute, 10)
+
+	first := continuations.Put(http.StatusOK, []byte("012345"), 6, false)
+	second := continuations.Put(http.StatusOK, []byte("6789"), 4, false)
+	third := continuations.Put(http.StatusOK, []byte("ab"), 2, false)
+
+	_, err := continuations.Next(first)
+	assert.ErrorIs(t, err, request.ErrContinuationNotFound)
+	for _, token := range []string{second, third} {
+		_, err := continuations.Next(token)
+		assert.NoError(t, err)
+	}
+}
+
+func TestExecute_TruncatesWithoutContinuation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/big",
+	}
+
+	ex := request.NewExecutor(request.WithMaxResponseSize(4))
+
+	result, err := ex.Execute(context.Background(), req, map[string]string{})
+	assert.NoError(t, err)
+
+	var resp types.Response
+	assert.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.Equal(t, "0123", resp.Body)
+	assert.True(t, resp.Truncated)
+	assert.Empty(t, resp.Continuation)
+}
+
+func TestExecute_StatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		w.Header().Set("X-Other", "ignored")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Project Not Found"}`))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:          http.MethodGet,
+		Host:            ts.URL[len("http://"):],
+		Endpoint:        "/projects/1",
+		ResponseHeaders: []string{"X-Request-Id"},
+		StatusHints: map[string]string{
+			"404": "project not found, check project_id",
+			"5xx": "upstream unavailable",
+		},
+	}
+
+	ex := request.NewExecutor()
+	_, err := ex.Execute(context.Background(), req, map[string]string{})
+
+	var statusErr *request.StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.Response.StatusCode)
+	assert.Equal(t, "404 Not Found", statusErr.Response.Status)
+	assert.Equal(t, map[string]string{"X-Request-Id": "abc"}, statusErr.Response.Headers)
+	assert.Equal(t, map[string]any{"message": "404 Project Not Found"}, statusErr.Response.Body)
+	assert.Equal(t, "project not found, check project_id", statusErr.Response.Hint)
+}
+
+func TestExecute_StatusErrorClassHint(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("bad gateway"))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:      http.MethodGet,
+		Host:        ts.URL[len("http://"):],
+		Endpoint:    "/",
+		StatusHints: map[string]string{"5xx": "upstream unavailable"},
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{})
+
+	var statusErr *request.StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, "bad gateway", statusErr.Response.Body)
+	assert.Equal(t, "upstream unavailable", statusErr.Response.Hint)
+}
+
+func TestExecute_HeaderParamsAndTemplates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant-Id"))
+		assert.Equal(t, `W/"v1"`, r.Header.Get("If-Match"))
+		assert.Equal(t, "static", r.Header.Get("X-Static"))
+		_, hasLang := r.Header["Accept-Language"]
+		assert.False(t, hasLang)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+		Headers: map[string]string{
+			"X-Static": "static",
+			"If-Match": `W/"{{arg version}}"`,
+		},
+		HeaderParams: []string{"X-Tenant-Id", "Accept-Language"},
+	}
+
+	ex := request.NewExecutor()
+	_, err := ex.Execute(context.Background(), req, map[string]string{
+		"version":         "v1",
+		"X-Tenant-Id":     "acme",
+		"Accept-Language": "",
+	})
+	assert.NoError(t, err)
+}
+
+func TestExecute_HeaderInjection(t *testing.T) {
+	tests := []struct {
+		name string
+		req  types.Request
+		args map[string]string
+	}{
+		{
+			name: "header param",
+			req:  types.Request{HeaderParams: []string{"X-Tenant-Id"}},
+			args: map[string]string{"X-Tenant-Id": "acme\r\nX-Admin: true"},
+		},
+		{
+			name: "templated header",
+			req:  types.Request{Headers: map[string]string{"X-Tenant-Id": "{{arg tenant}}"}},
+			args: map[string]string{"tenant": "acme\nX-Admin: true"},
+		},
+		{
+			name: "unknown arg",
+			req:  types.Request{Headers: map[string]string{"X-Tenant-Id": "{{arg tenant}}"}},
+			args: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Method = http.MethodGet
+			tt.req.Host = "example.com"
+			tt.req.Endpoint = "/"
+
+			_, err := request.NewExecutor().Execute(context.Background(), tt.req, tt.args)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid header")
+		})
+	}
+}
+
+func TestExecute_Auth(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   types.Auth
+		assert func(t *testing.T, r *http.Request)
+	}{
+		{
+			name: "bearer",
+			auth: types.Auth{Type: request.AuthBearer, Token: "secret"},
+			assert: func(t *testing.T, r *http.Request) {
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			},
+		},
+		{
+			name: "basic",
+			auth: types.Auth{Type: request.AuthBasic, Username: "user", Password: "pass"},
+			assert: func(t *testing.T, r *http.Request) {
+				user, pass, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "user", user)
+				assert.Equal(t, "pass", pass)
+			},
+		},
+		{
+			name: "api key header",
+			auth: types.Auth{Type: request.AuthAPIKey, Name: "X-Api-Key", Token: "secret"},
+			assert: func(t *testing.T, r *http.Request) {
+				assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+			},
+		},
+		{
+			name: "api key query",
+			auth: types.Auth{Type: request.AuthAPIKey, Name: "key", In: "query", Token: "secret"},
+			assert: func(t *testing.T, r *http.Request) {
+				assert.Equal(t, "secret", r.URL.Query().Get("key"))
+				assert.Equal(t, "test", r.URL.Query().Get("q"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.assert(t, r)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer ts.Close()
+
+			req := types.Request{
+				Method:      http.MethodGet,
+				Host:        ts.URL[len("http://"):],
+				Endpoint:    "/",
+				QueryParams: []string{"q"},
+				Auth:        &tt.auth,
+			}
+
+			_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{"q": "test"})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestExecute_AuthExplicitHeaderWins(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer explicit", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+		Headers:  map[string]string{"Authorization": "Bearer explicit"},
+		Auth:     &types.Auth{Type: request.AuthBearer, Token: "secret"},
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, nil)
+	assert.NoError(t, err)
+}
+
+func TestExecute_AuthUnsupportedType(t *testing.T) {
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     "example.com",
+		Endpoint: "/",
+		Auth:     &types.Auth{Type: "digest"},
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, nil)
+	assert.ErrorContains(t, err, "unsupported auth type: digest")
+}
+
+func TestExecute_Secrets(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tenant"), []byte("acme"), 0600))
+
+	store := secret.NewStore()
+	store.Register("files", secret.NewFileProvider(dir), 0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+		Headers:  map[string]string{"X-Tenant": "{{secret files tenant}}"},
+		Auth:     &types.Auth{Type: request.AuthBearer, Token: "{{secret files token}}"},
+	}
+
+	_, err := request.NewExecutor(request.WithSecrets(store)).Execute(context.Background(), req, nil)
+	assert.NoError(t, err)
+
+	_, err = request.NewExecutor().Execute(context.Background(), req, nil)
+	assert.ErrorContains(t, err, "no secret store configured")
+}
+
+func TestExecute_SecretsMaskedInLogs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret-token"), 0600))
+
+	store := secret.NewStore()
+	store.Register("files", secret.NewFileProvider(dir), 0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("invalid token " + r.Header.Get("X-Token")))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(redact.New().Handler(slog.NewTextHandler(&buf, nil))))
+	defer slog.SetDefault(prev)
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+		Headers:  map[string]string{"X-Token": "{{secret files token}}"},
+	}
+	_, err := request.NewExecutor(request.WithSecrets(store)).Execute(context.Background(), req, nil)
+
+	var statusErr *request.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Contains(t, buf.String(), "invalid token [REDACTED]")
+	assert.NotContains(t, buf.String(), "s3cret-token")
+}
+
+func TestExecute_Retry(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`ok`))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+		Retry:    &types.Retry{Attempts: 3, Backoff: "1ms"},
+	}
+
+	result, err := request.NewExecutor().Execute(context.Background(), req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	var resp types.Response
+	assert.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestExecute_RetryGivesUp(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		calls  int
+	}{
+		{name: "attempts exhausted", method: http.MethodGet, calls: 2},
+		{name: "method not retried", method: http.MethodPost, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer ts.Close()
+
+			req := types.Request{
+				Method:   tt.method,
+				Host:     ts.URL[len("http://"):],
+				Endpoint: "/",
+				Retry:    &types.Retry{Attempts: 2, Backoff: "1ms"},
+			}
+
+			_, err := request.NewExecutor().Execute(context.Background(), req, nil)
+			var statusErr *request.StatusError
+			assert.ErrorAs(t, err, &statusErr)
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func TestExecute_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+		Timeout:  "20ms",
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestExecute_DefaultTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(50 * time.Millisecond):
+		}
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:   http.MethodGet,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/",
+	}
+	executor := request.NewExecutor(request.WithTimeout(10 * time.Millisecond))
+
+	_, err := executor.Execute(context.Background(), req, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	req.Timeout = "1s"
+	_, err = executor.Execute(context.Background(), req, nil)
+	assert.NoError(t, err)
+}
+
 type errReader struct {
 	err error
 }
@@ -97,3 +639,252 @@ type errorTransportFunc func(*http.Request) (*http.Response, error)
 func (f errorTransportFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 	return f(r)
 }
+
+func TestExecute_Breaker(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	host := ts.URL[len("http://"):]
+	b := breaker.New(breaker.WithMinRequests(2))
+	ex := request.NewExecutor(request.WithBreaker(b))
+	req := types.Request{Method: http.MethodGet, Host: host, Endpoint: "/"}
+
+	for range 2 {
+		_, err := ex.Execute(context.Background(), req, nil)
+		var statusErr *request.StatusError
+		assert.ErrorAs(t, err, &statusErr)
+	}
+	assert.Equal(t, breaker.Open, b.State(host))
+
+	_, err := ex.Execute(context.Background(), req, nil)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.ErrorContains(t, err, "request failed: circuit breaker is open for "+host)
+	assert.Equal(t, 2, calls)
+}
+
+func TestExecute_BreakerIgnoresCanceledProbe(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	host := ts.URL[len("http://"):]
+	now := time.Unix(0, 0)
+	b := breaker.New(
+		breaker.WithMinRequests(1),
+		breaker.WithOpenTimeout(time.Second),
+		breaker.WithClock(func() time.Time { return now }),
+	)
+	ex := request.NewExecutor(request.WithBreaker(b))
+	req := types.Request{Method: http.MethodGet, Host: host, Endpoint: "/"}
+
+	_, err := ex.Execute(context.Background(), req, nil)
+	require.Error(t, err)
+	assert.Equal(t, breaker.Open, b.State(host))
+
+	now = now.Add(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ex.Execute(ctx, req, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, breaker.HalfOpen, b.State(host))
+
+	status = http.StatusOK
+	_, err = ex.Execute(context.Background(), req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, breaker.Closed, b.State(host))
+}
+
+func TestExecute_Bulkhead(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	host := ts.URL[len("http://"):]
+	bh := bulkhead.New(bulkhead.WithMaxInFlightPerHost(1), bulkhead.WithQueueTimeout(0))
+	b := breaker.New(breaker.WithMinRequests(1))
+	ex := request.NewExecutor(request.WithBulkhead(bh), request.WithBreaker(b))
+	req := types.Request{Method: http.MethodGet, Host: host, Endpoint: "/"}
+
+	release, err := bh.Acquire(context.Background(), host)
+	require.NoError(t, err)
+	_, err = ex.Execute(context.Background(), req, nil)
+	assert.ErrorIs(t, err, bulkhead.ErrFull)
+	assert.Equal(t, breaker.Closed, b.State(host))
+
+	release()
+	res, err := ex.Execute(context.Background(), req, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status_code":200,"body":"ok"}`, res)
+
+	// The slot of the call is freed once its response is read.
+	release, err = bh.Acquire(context.Background(), host)
+	require.NoError(t, err)
+	release()
+}
+
+func TestExecute_Cache(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Version", "1")
+		_, _ = w.Write([]byte(`{"id":` + r.URL.Query().Get("id") + `}`))
+	}))
+	defer ts.Close()
+
+	ex := request.NewExecutor(request.WithCache(cache.New(cache.NewMemory(0))))
+	req := types.Request{
+		Method:          http.MethodGet,
+		Host:            ts.URL[len("http://"):],
+		Endpoint:        "/todos",
+		QueryParams:     []string{"id"},
+		ResponseHeaders: []string{"X-Version"},
+		Cache:           &types.Cache{TTL: "1m"},
+	}
+
+	first, err := ex.Execute(context.Background(), req, map[string]string{"id": "1"})
+	require.NoError(t, err)
+	second, err := ex.Execute(context.Background(), req, map[string]string{"id": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.JSONEq(t, `{"status_code":200,"body":"{\"id\":1}","headers":{"X-Version":"1"}}`, second)
+	assert.Equal(t, 1, calls)
+
+	_, err = ex.Execute(context.Background(), req, map[string]string{"id": "2"})
+	require.NoError(t, err)
+	req.Cache = nil
+	_, err = ex.Execute(context.Background(), req, map[string]string{"id": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestExecute_Coalescing(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"id":` + r.URL.Query().Get("id") + `}`))
+	}))
+	defer ts.Close()
+
+	var coalesced atomic.Int32
+	ex := request.NewExecutor(request.WithCoalescing(func(host string) {
+		assert.Equal(t, ts.URL[len("http://"):], host)
+		coalesced.Add(1)
+	}))
+	req := types.Request{
+		Method:      http.MethodGet,
+		Host:        ts.URL[len("http://"):],
+		Endpoint:    "/todos",
+		QueryParams: []string{"id"},
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := ex.Execute(context.Background(), req, map[string]string{"id": "1"})
+			assert.NoError(t, err)
+			results[i] = res
+		}()
+	}
+
+	// The calls waiting on the first one are only counted once it returns, so wait for them to join it.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int32(4), coalesced.Load())
+	for _, res := range results {
+		assert.JSONEq(t, `{"status_code":200,"body":"{\"id\":1}"}`, res)
+	}
+
+	req.Method = http.MethodPost
+	_, err := ex.Execute(context.Background(), req, map[string]string{"id": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestExecute_CoalescingLeaderDeadline(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	ex := request.NewExecutor(request.WithCoalescing(nil))
+	req := types.Request{Method: http.MethodGet, Host: ts.URL[len("http://"):], Endpoint: "/"}
+
+	// The leader gives up before the others, which then make their own round trip.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := ex.Execute(ctx, req, nil)
+		leaderErr <- err
+	}()
+	<-started
+
+	followerRes := make(chan string, 1)
+	go func() {
+		res, err := ex.Execute(context.Background(), req, nil)
+		assert.NoError(t, err)
+		followerRes <- res
+	}()
+
+	assert.ErrorIs(t, <-leaderErr, context.DeadlineExceeded)
+	assert.JSONEq(t, `{"status_code":200,"body":"ok"}`, <-followerRes)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestExecute_CoalescingLargeBody(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	body := strings.Repeat("a", 8<<20+1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	ex := request.NewExecutor(request.WithCoalescing(nil))
+	req := types.Request{Method: http.MethodGet, Host: ts.URL[len("http://"):], Endpoint: "/"}
+
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := ex.Execute(context.Background(), req, nil)
+			assert.NoError(t, err)
+			results[i] = res
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// The leader streams its response, and the others make their own round trips.
+	assert.Equal(t, int32(3), calls.Load())
+	for _, res := range results {
+		assert.Contains(t, res, body)
+	}
+}
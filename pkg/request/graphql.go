@@ -0,0 +1,68 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"io"
+)
+
+const (
+	// KindHTTP is the default request kind, which configs may also name "http".
+	KindHTTP    = ""
+	KindGraphQL = "graphql"
+)
+
+// NormalizeKind returns KindHTTP for "http", and kind otherwise.
+func NormalizeKind(kind string) string {
+	if kind == "http" {
+		return KindHTTP
+	}
+	return kind
+}
+
+type graphQLPayload struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+func (e *executor) buildGraphQLBody(ctx context.Context, request types.Request, args map[string]string) (io.Reader, string, error) {
+	if request.GraphQL == nil || request.GraphQL.Query == "" {
+		return nil, "", errors.New("graphql request has no query")
+	}
+
+	payload := graphQLPayload{
+		Query:         request.GraphQL.Query,
+		OperationName: request.GraphQL.OperationName,
+	}
+
+	if len(request.GraphQL.Variables) > 0 {
+		payload.Variables = make(map[string]any, len(request.GraphQL.Variables))
+		for _, name := range request.GraphQL.Variables {
+			if val, ok := args[name]; ok && !Omitted(ctx, name) {
+				payload.Variables[name] = TypedValue(request.ArgTypes[name], val)
+			}
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode graphql request: %w", err)
+	}
+	return bytes.NewReader(data), "application/json", nil
+}
+
+// graphQLErrors returns the errors field of a GraphQL response, if any.
+func (e *executor) graphQLErrors(body []byte) ([]any, bool) {
+	var resp struct {
+		Errors []any `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Errors) == 0 {
+		return nil, false
+	}
+	return resp.Errors, true
+}
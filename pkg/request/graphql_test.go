@@ -0,0 +1,113 @@
+package request_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExecute_GraphQL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/graphql", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var payload map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "query($id: ID!, $first: Int) { project(id: $id) { issues(first: $first) { title } } }", payload["query"])
+		assert.Equal(t, map[string]any{"id": "gitlab-org/gitlab", "first": float64(5), "archived": false}, payload["variables"])
+
+		_, _ = w.Write([]byte(`{"data":{"project":{"issues":[]}}}`))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Kind:     request.KindGraphQL,
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/graphql",
+		GraphQL: &types.GraphQLRequest{
+			Query:     "query($id: ID!, $first: Int) { project(id: $id) { issues(first: $first) { title } } }",
+			Variables: []string{"id", "first", "archived"},
+		},
+		ArgTypes: map[string]string{"id": "string", "first": "int", "archived": "bool"},
+	}
+
+	result, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{
+		"id":       "gitlab-org/gitlab",
+		"first":    "5",
+		"archived": "false",
+	})
+	assert.NoError(t, err)
+
+	var resp types.Response
+	assert.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.JSONEq(t, `{"data":{"project":{"issues":[]}}}`, resp.Body)
+}
+
+func TestExecute_GraphQLOmittedVariables(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			return
+		}
+		var payload map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, map[string]any{"id": "gitlab-org/gitlab"}, payload["variables"])
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Kind:     "http",
+		Host:     ts.URL[len("http://"):],
+		Endpoint: "/graphql",
+	}
+	_, err := request.NewExecutor().Execute(context.Background(), req, nil)
+	assert.NoError(t, err, "http names the default kind")
+
+	req.Kind = request.KindGraphQL
+	req.GraphQL = &types.GraphQLRequest{
+		Query:     "query($id: ID!, $first: Int) { project(id: $id) { issues(first: $first) { title } } }",
+		Variables: []string{"id", "first"},
+	}
+	req.ArgTypes = map[string]string{"id": "string", "first": "int"}
+
+	ctx := request.WithOmittedArgs(context.Background(), "first")
+	_, err = request.NewExecutor().Execute(ctx, req, map[string]string{"id": "gitlab-org/gitlab", "first": "0"})
+	assert.NoError(t, err)
+}
+
+func TestExecute_GraphQLErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"Project not found"}]}`))
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Kind:        request.KindGraphQL,
+		Host:        ts.URL[len("http://"):],
+		Endpoint:    "/graphql",
+		GraphQL:     &types.GraphQLRequest{Query: "{ project { id } }"},
+		StatusHints: map[string]string{"graphql": "check the project path"},
+	}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{})
+
+	var statusErr *request.StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, "graphql request failed", statusErr.Error())
+	assert.Equal(t, map[string]any{"errors": []any{map[string]any{"message": "Project not found"}}}, statusErr.Response.Body)
+	assert.Equal(t, "check the project path", statusErr.Response.Hint)
+}
+
+func TestExecute_GraphQLMissingQuery(t *testing.T) {
+	req := types.Request{Kind: request.KindGraphQL, Host: "example.com", Endpoint: "/graphql"}
+
+	_, err := request.NewExecutor().Execute(context.Background(), req, map[string]string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no query")
+}
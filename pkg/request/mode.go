@@ -0,0 +1,144 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrReadOnly is returned in read-only mode for requests that may modify upstream data.
+var ErrReadOnly = errors.New("read-only mode: request may modify data")
+
+// graphQLMutation matches the mutation keyword of a GraphQL document.
+var graphQLMutation = regexp.MustCompile(`\bmutation\b`)
+
+// ReadOnly reports whether a request only reads data: an HTTP GET or HEAD request, or a GraphQL request
+// without mutations. Requests of other kinds are never considered read-only.
+func ReadOnly(request types.Request) bool {
+	method := strings.ToUpper(request.Method)
+	switch NormalizeKind(request.Kind) {
+	case KindHTTP:
+		return method == "" || method == http.MethodGet || method == http.MethodHead
+	case KindGraphQL:
+		query := ""
+		if request.GraphQL != nil {
+			query = request.GraphQL.Query
+		}
+		return (method == "" || method == http.MethodGet || method == http.MethodPost) && !graphQLMutation.MatchString(query)
+	default:
+		return false
+	}
+}
+
+// operation names a request refused in read-only mode.
+func operation(request types.Request) string {
+	switch NormalizeKind(request.Kind) {
+	case KindHTTP:
+		return strings.ToUpper(request.Method) + " " + request.Endpoint
+	case KindGraphQL:
+		return "graphql mutation"
+	default:
+		return request.Kind + " request"
+	}
+}
+
+// WithReadOnly refuses requests that are not ReadOnly with ErrReadOnly.
+func WithReadOnly() Option {
+	return func(e *executor) {
+		e.readOnly = true
+	}
+}
+
+// WithDryRun returns a description of each request, as types.DryRun, instead of sending it.
+func WithDryRun() Option {
+	return func(e *executor) {
+		e.dryRun = true
+	}
+}
+
+// WithRedactor sets the redactor masking credentials in dry-run results. It defaults to redact.New().
+func WithRedactor(r *redact.Redactor) Option {
+	return func(e *executor) {
+		e.redactor = r
+	}
+}
+
+// dryRunResult describes a resolved HTTP request, with credentials in its URL, headers and body masked.
+func (e *executor) dryRunResult(request types.Request, req *http.Request) (string, error) {
+	r := e.redactor
+	if r == nil {
+		r = redact.New()
+	}
+
+	// The API key is masked here, as its name is only sensitive for this request.
+	u := *req.URL
+	var authHeader string
+	if auth := request.Auth; auth != nil && auth.Type == AuthAPIKey && auth.Name != "" {
+		if auth.In == "query" {
+			u.RawQuery = maskParam(u.RawQuery, auth.Name)
+		} else {
+			authHeader = auth.Name
+		}
+	}
+
+	ctx := req.Context()
+	result := types.DryRun{
+		DryRun:  true,
+		Kind:    request.Kind,
+		Method:  req.Method,
+		URL:     r.StringContext(ctx, u.String()),
+		Headers: make(map[string]string, len(req.Header)),
+	}
+	for name, values := range req.Header {
+		if r.KeyContext(ctx, name) || strings.EqualFold(name, authHeader) {
+			result.Headers[name] = redact.Mask
+		} else {
+			result.Headers[name] = r.StringContext(ctx, strings.Join(values, ", "))
+		}
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read request body: %w", err)
+		}
+		result.Body = r.StringContext(ctx, string(body))
+	}
+
+	return marshalDryRun(result)
+}
+
+// maskParam masks the values of a query param, leaving the rest of the query as it is.
+func maskParam(rawQuery, name string) string {
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if k, err := url.QueryUnescape(key); err == nil && k == name {
+			pairs[i] = key + "=" + redact.Mask
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// dryRunKind describes a request of a kind handled by another executor, which is not resolved further.
+func (e *executor) dryRunKind(request types.Request) (string, error) {
+	result := types.DryRun{DryRun: true, Kind: request.Kind, Method: request.Method, URL: request.Host}
+	if request.GRPC != nil {
+		result.Method = request.GRPC.Method
+	}
+	return marshalDryRun(result)
+}
+
+func marshalDryRun(result types.DryRun) (string, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,125 @@
+package request_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  types.Request
+		readOnly bool
+	}{
+		{"default method", types.Request{}, true},
+		{"get", types.Request{Method: "get"}, true},
+		{"head", types.Request{Method: http.MethodHead}, true},
+		{"post", types.Request{Method: http.MethodPost}, false},
+		{"delete", types.Request{Method: http.MethodDelete}, false},
+		{"graphql query", types.Request{Kind: request.KindGraphQL, GraphQL: &types.GraphQLRequest{Query: "query { items { id } }"}}, true},
+		{"graphql mutation", types.Request{Kind: request.KindGraphQL, GraphQL: &types.GraphQLRequest{Query: "mutation { delete(id: 1) }"}}, false},
+		{"grpc", types.Request{Kind: "grpc", GRPC: &types.GRPCRequest{Method: "items.v1.Items/Get"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.readOnly, request.ReadOnly(tt.request))
+		})
+	}
+}
+
+func TestExecute_ReadOnly(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	ex := request.NewExecutor(request.WithReadOnly())
+	host := ts.URL[len("http://"):]
+
+	_, err := ex.Execute(context.Background(), types.Request{Method: http.MethodPost, Host: host, Endpoint: "/todos"}, nil)
+	assert.ErrorIs(t, err, request.ErrReadOnly)
+	assert.ErrorContains(t, err, "POST /todos")
+	assert.Equal(t, 0, calls)
+
+	_, err = ex.Execute(context.Background(), types.Request{Method: http.MethodGet, Host: host, Endpoint: "/todos"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestExecute_DryRun(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("dry run sent a request")
+	}))
+	defer ts.Close()
+
+	req := types.Request{
+		Method:      http.MethodPost,
+		Host:        ts.URL[len("http://"):],
+		Endpoint:    "/users/:id",
+		PathParams:  []string{"id"},
+		QueryParams: []string{"notify"},
+		Headers:     map[string]string{"X-Trace": "{{arg trace}}"},
+		Body:        "body",
+		Auth:        &types.Auth{Type: request.AuthAPIKey, Name: "key", In: "query", Token: "s3cr3t"},
+	}
+	redactor := redact.New(redact.WithHeaders("X-Trace"))
+	ex := request.NewExecutor(request.WithDryRun(), request.WithRedactor(redactor))
+
+	result, err := ex.Execute(context.Background(), req, map[string]string{
+		"id":     "7",
+		"notify": "true",
+		"trace":  "abc",
+		"body":   `{"name":"bob","password":"hunter2"}`,
+	})
+	require.NoError(t, err)
+
+	var dryRun types.DryRun
+	require.NoError(t, json.Unmarshal([]byte(result), &dryRun))
+	assert.True(t, dryRun.DryRun)
+	assert.Equal(t, http.MethodPost, dryRun.Method)
+	assert.Equal(t, ts.URL+"/users/7?key=[REDACTED]&notify=true", dryRun.URL)
+	assert.Equal(t, redact.Mask, dryRun.Headers["X-Trace"])
+	assert.Equal(t, `{"name":"bob","password":"[REDACTED]"}`, dryRun.Body)
+	assert.False(t, redactor.Key("key"))
+
+	req.Auth = &types.Auth{Type: request.AuthAPIKey, Name: "x-custom-key", Token: "s3cr3t"}
+	result, err = ex.Execute(context.Background(), req, map[string]string{"id": "7", "trace": "abc"})
+	require.NoError(t, err)
+
+	require.NoError(t, json.Unmarshal([]byte(result), &dryRun))
+	assert.Equal(t, redact.Mask, dryRun.Headers["X-Custom-Key"])
+	assert.False(t, redactor.Key("x-custom-key"))
+}
+
+func TestExecute_DryRunKind(t *testing.T) {
+	delegate := executorFunc(func(context.Context, types.Request, map[string]string) (string, error) {
+		t.Error("dry run called the kind executor")
+		return "", nil
+	})
+	ex := request.NewExecutor(request.WithDryRun(), request.WithKindExecutor("grpc", delegate))
+
+	result, err := ex.Execute(context.Background(), types.Request{
+		Kind: "grpc",
+		Host: "items:9090",
+		GRPC: &types.GRPCRequest{Method: "items.v1.Items/Delete"},
+	}, nil)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"dry_run":true,"kind":"grpc","method":"items.v1.Items/Delete","url":"items:9090"}`, result)
+}
+
+type executorFunc func(context.Context, types.Request, map[string]string) (string, error)
+
+func (f executorFunc) Execute(ctx context.Context, req types.Request, args map[string]string) (string, error) {
+	return f(ctx, req, args)
+}
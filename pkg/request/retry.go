@@ -0,0 +1,139 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultRetryMaxBackoff = 5 * time.Second
+)
+
+var (
+	defaultRetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	defaultRetryMethods     = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete}
+)
+
+type retryPolicy struct {
+	attempts    int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	statusCodes []int
+	methods     []string
+}
+
+// newRetryPolicy applies the defaults to a retry config. Without one, requests are sent once.
+func newRetryPolicy(cfg *types.Retry) (retryPolicy, error) {
+	if cfg == nil {
+		return retryPolicy{attempts: 1}, nil
+	}
+
+	policy := retryPolicy{
+		attempts:    cfg.Attempts,
+		backoff:     defaultRetryBackoff,
+		maxBackoff:  defaultRetryMaxBackoff,
+		statusCodes: cfg.StatusCodes,
+		methods:     cfg.Methods,
+	}
+	if policy.attempts <= 0 {
+		policy.attempts = defaultRetryAttempts
+	}
+	if len(policy.statusCodes) == 0 {
+		policy.statusCodes = defaultRetryStatusCodes
+	}
+	if len(policy.methods) == 0 {
+		policy.methods = defaultRetryMethods
+	}
+
+	var err error
+	if cfg.Backoff != "" {
+		if policy.backoff, err = time.ParseDuration(cfg.Backoff); err != nil {
+			return policy, fmt.Errorf("invalid retry backoff: %w", err)
+		}
+	}
+	if cfg.MaxBackoff != "" {
+		if policy.maxBackoff, err = time.ParseDuration(cfg.MaxBackoff); err != nil {
+			return policy, fmt.Errorf("invalid retry max backoff: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// retryable reports whether a failed attempt may be retried: transport errors and the policy status codes,
+// for the policy methods only.
+func (p retryPolicy) retryable(method string, resp *http.Response, err error) bool {
+	if !slices.ContainsFunc(p.methods, func(m string) bool { return strings.EqualFold(m, method) }) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return slices.Contains(p.statusCodes, resp.StatusCode)
+}
+
+// delay returns the exponential backoff before the next attempt, or the Retry-After of the response when
+// given in seconds, bounded by the max backoff.
+func (p retryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	delay := p.backoff << (attempt - 1)
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			delay = time.Duration(secs) * time.Second
+		}
+	}
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	return delay
+}
+
+// do sends the request, then rebuilds and resends it while the attempt fails in a way the policy retries.
+func (e *executor) do(ctx context.Context, policy retryPolicy, req *http.Request, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := e.httpClient.Do(req)
+		if attempt >= policy.attempts || ctx.Err() != nil || !policy.retryable(req.Method, resp, err) {
+			return resp, err
+		}
+
+		delay := policy.delay(attempt, resp)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		// The query is left out of the log as it may hold an API key.
+		slog.WarnContext(ctx, "retrying http request",
+			slog.Group("request",
+				slog.String("method", req.Method),
+				slog.String("url", (&url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path}).String()),
+			),
+			slog.Int("attempt", attempt),
+			slog.String("reason", reason),
+			slog.Duration("delay", delay),
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if req, err = newRequest(); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/AdamShannag/api-mcp-server/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
+	"maps"
+	"slices"
 )
 
 type CallToolRequest interface {
@@ -43,6 +45,11 @@ func (r *TypeResolverRegistry) Register(argType string, resolver ArgResolver) {
 	r.resolvers[argType] = resolver
 }
 
+// Types returns the registered arg types, sorted.
+func (r *TypeResolverRegistry) Types() []string {
+	return slices.Sorted(maps.Keys(r.resolvers))
+}
+
 func (r *TypeResolverRegistry) Resolve(ctx context.Context, req CallToolRequest, arg types.Arg) (string, error) {
 	resolver, ok := r.resolvers[arg.Type]
 	if !ok {
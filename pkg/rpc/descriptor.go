@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"os"
+	"strings"
+)
+
+type methodDescriptor = protoreflect.MethodDescriptor
+
+type resolver interface {
+	FindDescriptorByName(protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
+// splitMethod splits "package.Service/Method" (or "package.Service.Method") into service and method names.
+func splitMethod(fullMethod string) (string, string, error) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	idx := strings.LastIndexAny(fullMethod, "/.")
+	if idx <= 0 || idx == len(fullMethod)-1 {
+		return "", "", fmt.Errorf("invalid grpc method %q, expected package.Service/Method", fullMethod)
+	}
+	return fullMethod[:idx], fullMethod[idx+1:], nil
+}
+
+func findMethod(files resolver, serviceName, methodName string) (methodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("service %q not found: %w", serviceName, err)
+	}
+
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("method %q not found in service %q", methodName, serviceName)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("method %q is streaming, only unary methods are supported", method.FullName())
+	}
+
+	return method, nil
+}
+
+func loadProtoset(path string) (resolver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protoset: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err = proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode protoset: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protoset: %w", err)
+	}
+	return files, nil
+}
+
+// resolveWithReflection fetches the file defining the service, and its dependencies, through server reflection.
+func resolveWithReflection(ctx context.Context, conn *grpc.ClientConn, serviceName string) (resolver, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start server reflection: %w", err)
+	}
+	defer func() { _ = stream.CloseSend() }()
+
+	fetch := func(req *reflectionpb.ServerReflectionRequest) ([][]byte, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, errors.New(errResp.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	}
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	add := func(raw [][]byte) error {
+		for _, b := range raw {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(b, fd); err != nil {
+				return fmt.Errorf("failed to decode file descriptor: %w", err)
+			}
+			files[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	raw, err := fetch(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: serviceName},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server reflection failed for %q: %w", serviceName, err)
+	}
+	if err = add(raw); err != nil {
+		return nil, err
+	}
+
+	for missing := missingDependencies(files); len(missing) > 0; missing = missingDependencies(files) {
+		for _, name := range missing {
+			raw, err = fetch(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("server reflection failed for %q: %w", name, err)
+			}
+			if err = add(raw); err != nil {
+				return nil, err
+			}
+			if _, ok := files[name]; !ok {
+				return nil, fmt.Errorf("server reflection did not return %q", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range files {
+		set.File = append(set.File, fd)
+	}
+
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from server reflection: %w", err)
+	}
+	return registry, nil
+}
+
+func missingDependencies(files map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, fd := range files {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := files[dep]; !ok {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,340 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/netguard"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const KindGRPC = "grpc"
+
+type Option func(*executor)
+
+type executor struct {
+	dialOptions []grpc.DialOption
+	secrets     *secret.Store
+	guard       *netguard.Guard
+
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	methods map[string]methodDescriptor
+}
+
+// NewExecutor creates an executor calling unary gRPC methods described by a request's GRPC settings.
+func NewExecutor(opts ...Option) request.Executor {
+	e := &executor{
+		conns:   make(map[string]*grpc.ClientConn),
+		methods: make(map[string]methodDescriptor),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// WithDialOptions adds options used when connecting to upstream gRPC servers.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(e *executor) {
+		e.dialOptions = append(e.dialOptions, opts...)
+	}
+}
+
+// WithSecrets resolves {{secret <provider> <key>}} placeholders in metadata headers from the store.
+func WithSecrets(store *secret.Store) Option {
+	return func(e *executor) {
+		e.secrets = store
+	}
+}
+
+// WithGuard checks the target of every request against the host allow-lists of the guard, as the
+// http transport of the guard does. Dial with guard.DialContext to refuse blocked addresses as well.
+func WithGuard(guard *netguard.Guard) Option {
+	return func(e *executor) {
+		e.guard = guard
+	}
+}
+
+func (e *executor) Execute(ctx context.Context, req types.Request, argValues map[string]string) (string, error) {
+	if req.GRPC == nil || req.GRPC.Method == "" {
+		return "", errors.New("grpc request has no method")
+	}
+	if e.guard != nil {
+		scheme := "http"
+		if req.Secure {
+			scheme = "https"
+		}
+		if err := e.guard.Check(ctx, &url.URL{Scheme: scheme, Host: req.Host}); err != nil {
+			return "", err
+		}
+	}
+
+	conn, err := e.conn(req.Host, req.Secure)
+	if err != nil {
+		return "", err
+	}
+
+	method, err := e.method(ctx, conn, req)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := e.buildInput(ctx, req, method.Input(), argValues)
+	if err != nil {
+		return "", err
+	}
+
+	in := dynamicpb.NewMessage(method.Input())
+	if err = protojson.Unmarshal(input, in); err != nil {
+		return "", fmt.Errorf("invalid input for %s: %w", method.FullName(), err)
+	}
+
+	// The secrets resolved into the metadata are masked in the logs of the call.
+	var secrets []string
+	md, err := buildMetadata(req, argValues, secret.Record(e.secrets.Func(ctx), &secrets))
+	if err != nil {
+		return "", err
+	}
+	ctx = redact.WithValues(ctx, secrets...)
+
+	slog.InfoContext(ctx, "executing grpc request",
+		slog.Group("request",
+			slog.String("method", string(method.FullName())),
+			slog.String("target", req.Host),
+		),
+	)
+
+	out := dynamicpb.NewMessage(method.Output())
+	var header metadata.MD
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+
+	err = conn.Invoke(metadata.NewOutgoingContext(ctx, md), fullMethod, in, out, grpc.Header(&header))
+	if err != nil {
+		st := status.Convert(err)
+		slog.ErrorContext(ctx, "grpc request failed",
+			slog.Group("request",
+				slog.String("method", string(method.FullName())),
+				slog.String("target", req.Host),
+				slog.String("code", st.Code().String()),
+			),
+		)
+		return "", &request.StatusError{
+			Message: fmt.Sprintf("grpc request failed: %s", st.Code()),
+			Response: types.ErrorResponse{
+				StatusCode: int(st.Code()),
+				Status:     st.Code().String(),
+				Headers:    selectMetadata(header, req.ResponseHeaders),
+				Body:       st.Message(),
+				Hint:       req.StatusHints[st.Code().String()],
+			},
+		}
+	}
+
+	body, err := protojson.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal grpc response: %w", err)
+	}
+
+	result, err := json.Marshal(types.Response{
+		Body:    string(body),
+		Headers: selectMetadata(header, req.ResponseHeaders),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+func (e *executor) conn(target string, secure bool) (*grpc.ClientConn, error) {
+	key := fmt.Sprintf("%t|%s", secure, target)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if conn, ok := e.conns[key]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if secure {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, e.dialOptions...)
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", target, err)
+	}
+
+	e.conns[key] = conn
+	return conn, nil
+}
+
+// method resolves and caches the method descriptor from the protoset file or server reflection.
+func (e *executor) method(ctx context.Context, conn *grpc.ClientConn, req types.Request) (methodDescriptor, error) {
+	key := fmt.Sprintf("%s|%s|%s", req.Host, req.GRPC.Protoset, req.GRPC.Method)
+
+	e.mu.Lock()
+	md, ok := e.methods[key]
+	e.mu.Unlock()
+	if ok {
+		return md, nil
+	}
+
+	serviceName, methodName, err := splitMethod(req.GRPC.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	var files resolver
+	switch {
+	case req.GRPC.Protoset != "":
+		files, err = loadProtoset(req.GRPC.Protoset)
+	case req.GRPC.Reflection:
+		files, err = resolveWithReflection(ctx, conn, serviceName)
+	default:
+		err = errors.New("grpc request needs either a protoset or reflection enabled")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	md, err = findMethod(files, serviceName, methodName)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.methods[key] = md
+	e.mu.Unlock()
+
+	return md, nil
+}
+
+// buildInput maps the args to the JSON form of the input message. The body arg, if any, holds a JSON
+// object used as the base message. Dotted arg names set nested fields. Without explicit fields, the args
+// naming a field of the input message are mapped. Optional args the caller did not pass are left out.
+func (e *executor) buildInput(ctx context.Context, req types.Request, input protoreflect.MessageDescriptor, args map[string]string) ([]byte, error) {
+	obj := make(map[string]any)
+	if req.Body != "" {
+		if raw := args[req.Body]; raw != "" {
+			if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+				return nil, fmt.Errorf("body %q is not a JSON object: %w", req.Body, err)
+			}
+		}
+	}
+
+	fields := req.GRPC.Fields
+	if len(fields) == 0 {
+		for name := range args {
+			top, _, _ := strings.Cut(name, ".")
+			if name != req.Body && (input.Fields().ByJSONName(top) != nil || input.Fields().ByName(protoreflect.Name(top)) != nil) {
+				fields = append(fields, name)
+			}
+		}
+	}
+
+	for _, name := range fields {
+		val, ok := args[name]
+		if !ok || request.Omitted(ctx, name) {
+			continue
+		}
+		if err := setPath(obj, strings.Split(name, "."), request.TypedValue(req.ArgTypes[name], val)); err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+	}
+
+	return json.Marshal(obj)
+}
+
+func buildMetadata(req types.Request, args map[string]string, secretFunc placeholder.Func) (metadata.MD, error) {
+	funcs := map[string]placeholder.Func{
+		"arg": func(name string) (string, error) {
+			val, ok := args[name]
+			if !ok {
+				return "", fmt.Errorf("unknown arg: %s", name)
+			}
+			return val, nil
+		},
+		"secret": secretFunc,
+	}
+
+	md := metadata.MD{}
+	for k, v := range req.Headers {
+		val, err := placeholder.Expand(v, funcs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %w", k, err)
+		}
+		if val != "" {
+			md.Set(k, val)
+		}
+	}
+	for _, name := range req.HeaderParams {
+		if val := args[name]; val != "" {
+			md.Set(name, val)
+		}
+	}
+
+	for k, vals := range md {
+		for _, v := range vals {
+			if strings.ContainsAny(v, "\r\n\x00") {
+				return nil, fmt.Errorf("invalid header %q: value must not contain CR, LF or NUL characters", k)
+			}
+		}
+	}
+	return md, nil
+}
+
+func setPath(obj map[string]any, path []string, val any) error {
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key]
+		if !ok {
+			child := make(map[string]any)
+			obj[key] = child
+			obj = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%q is not a message", key)
+		}
+		obj = child
+	}
+	obj[path[len(path)-1]] = val
+	return nil
+}
+
+func selectMetadata(md metadata.MD, names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(names))
+	for _, name := range names {
+		if vals := md.Get(name); len(vals) > 0 {
+			headers[name] = vals[0]
+		}
+	}
+	return headers
+}
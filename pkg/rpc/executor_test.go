@@ -0,0 +1,321 @@
+package rpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/netguard"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/rpc"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute_Protoset(t *testing.T) {
+	files, fdProto := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	protoset := filepath.Join(t.TempDir(), "greeter.protoset")
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdProto}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(protoset, data, 0644))
+
+	req := types.Request{
+		Kind:            rpc.KindGRPC,
+		Host:            addr,
+		Headers:         map[string]string{"x-tenant": "{{arg tenant}}"},
+		HeaderParams:    []string{"tenant"},
+		ResponseHeaders: []string{"x-served-by"},
+		GRPC: &types.GRPCRequest{
+			Method:   "test.Greeter/SayHello",
+			Protoset: protoset,
+		},
+		ArgTypes: map[string]string{"name": "string", "times": "int", "tenant": "string"},
+	}
+
+	result, err := rpc.NewExecutor().Execute(context.Background(), req, map[string]string{
+		"name":   "Ada",
+		"times":  "2",
+		"tenant": "acme",
+	})
+	require.NoError(t, err)
+
+	var resp types.Response
+	require.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.JSONEq(t, `{"message":"Hello Ada Hello Ada from acme"}`, resp.Body)
+	assert.Equal(t, map[string]string{"x-served-by": "greeter"}, resp.Headers)
+}
+
+func TestExecute_DefaultFields(t *testing.T) {
+	files, _ := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	req := types.Request{
+		Kind:    rpc.KindGRPC,
+		Host:    addr,
+		Headers: map[string]string{"x-tenant": "{{arg tenant}}"},
+		GRPC: &types.GRPCRequest{
+			Method:     "test.Greeter/SayHello",
+			Reflection: true,
+		},
+		ArgTypes: map[string]string{"name": "string", "times": "int", "tenant": "string"},
+	}
+
+	// tenant is only used by a header and times was omitted by the caller, so neither is a message field.
+	ctx := request.WithOmittedArgs(context.Background(), "times")
+	result, err := rpc.NewExecutor().Execute(ctx, req, map[string]string{
+		"name":   "Ada",
+		"times":  "0",
+		"tenant": "acme",
+	})
+	require.NoError(t, err)
+
+	var resp types.Response
+	require.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.JSONEq(t, `{"message":"Hello Ada from acme"}`, resp.Body)
+}
+
+func TestExecute_Reflection(t *testing.T) {
+	files, _ := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	req := types.Request{
+		Kind: rpc.KindGRPC,
+		Host: addr,
+		Body: "payload",
+		GRPC: &types.GRPCRequest{
+			Method:     "test.Greeter.SayHello",
+			Reflection: true,
+			Fields:     []string{"name"},
+		},
+		ArgTypes: map[string]string{"name": "string", "payload": "string"},
+	}
+
+	result, err := rpc.NewExecutor().Execute(context.Background(), req, map[string]string{
+		"name":    "Grace",
+		"payload": `{"times": 1}`,
+	})
+	require.NoError(t, err)
+
+	var resp types.Response
+	require.NoError(t, json.Unmarshal([]byte(result), &resp))
+	assert.JSONEq(t, `{"message":"Hello Grace"}`, resp.Body)
+}
+
+func TestExecute_StatusError(t *testing.T) {
+	files, _ := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	req := types.Request{
+		Kind:        rpc.KindGRPC,
+		Host:        addr,
+		StatusHints: map[string]string{"InvalidArgument": "name must not be empty"},
+		GRPC:        &types.GRPCRequest{Method: "test.Greeter/SayHello", Reflection: true},
+	}
+
+	_, err := rpc.NewExecutor().Execute(context.Background(), req, map[string]string{"name": ""})
+
+	var statusErr *request.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, int(codes.InvalidArgument), statusErr.Response.StatusCode)
+	assert.Equal(t, "InvalidArgument", statusErr.Response.Status)
+	assert.Equal(t, "name is required", statusErr.Response.Body)
+	assert.Equal(t, "name must not be empty", statusErr.Response.Hint)
+}
+
+func TestExecute_InvalidConfig(t *testing.T) {
+	files, _ := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	tests := []struct {
+		name string
+		grpc *types.GRPCRequest
+		args map[string]string
+		want string
+	}{
+		{name: "no method", grpc: nil, want: "no method"},
+		{name: "bad method", grpc: &types.GRPCRequest{Method: "SayHello", Reflection: true}, want: "invalid grpc method"},
+		{name: "no descriptor source", grpc: &types.GRPCRequest{Method: "test.Greeter/SayHello"}, want: "protoset or reflection"},
+		{name: "unknown method", grpc: &types.GRPCRequest{Method: "test.Greeter/Nope", Reflection: true}, want: "not found"},
+		{name: "unknown field", grpc: &types.GRPCRequest{Method: "test.Greeter/SayHello", Reflection: true, Fields: []string{"nope"}}, args: map[string]string{"nope": "x"}, want: "invalid input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := types.Request{Kind: rpc.KindGRPC, Host: addr, GRPC: tt.grpc}
+			_, err := rpc.NewExecutor().Execute(context.Background(), req, tt.args)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestExecute_Guard(t *testing.T) {
+	files, _ := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	req := types.Request{
+		Kind: rpc.KindGRPC,
+		Host: addr,
+		GRPC: &types.GRPCRequest{Method: "test.Greeter/SayHello", Reflection: true},
+	}
+
+	_, err := rpc.NewExecutor(rpc.WithGuard(netguard.New(netguard.WithAllowedHosts("api.example.com")))).
+		Execute(context.Background(), req, nil)
+	assert.ErrorIs(t, err, netguard.ErrHostNotAllowed)
+
+	ex := rpc.NewExecutor(rpc.WithGuard(netguard.New()))
+	_, err = ex.Execute(netguard.ContextWithHosts(context.Background(), []string{"api.example.com"}), req, nil)
+	assert.ErrorIs(t, err, netguard.ErrHostNotAllowed)
+
+	_, err = ex.Execute(netguard.ContextWithHosts(context.Background(), []string{addr}), req, map[string]string{"name": "Ada"})
+	assert.NoError(t, err)
+}
+
+func TestExecute_ViaKindExecutor(t *testing.T) {
+	files, _ := greeterDescriptors(t)
+	addr := startGreeter(t, files)
+
+	ex := request.NewExecutor(request.WithKindExecutor(rpc.KindGRPC, rpc.NewExecutor()))
+
+	req := types.Request{
+		Kind: rpc.KindGRPC,
+		Host: addr,
+		GRPC: &types.GRPCRequest{Method: "test.Greeter/SayHello", Reflection: true},
+	}
+
+	result, err := ex.Execute(context.Background(), req, map[string]string{"name": "Linus"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Hello Linus")
+}
+
+// greeterDescriptors builds the descriptors of:
+//
+//	service Greeter { rpc SayHello(HelloRequest) returns (HelloReply); }
+//	message HelloRequest { string name = 1; int32 times = 2; }
+//	message HelloReply { string message = 1; }
+func greeterDescriptors(t *testing.T) (*protoregistry.Files, *descriptorpb.FileDescriptorProto) {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/greeter.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("HelloRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("times", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				},
+			},
+			{
+				Name:  proto.String("HelloReply"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("message", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Greeter"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("SayHello"),
+						InputType:  proto.String(".test.HelloRequest"),
+						OutputType: proto.String(".test.HelloReply"),
+					},
+				},
+			},
+		},
+	}
+
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fd}})
+	require.NoError(t, err)
+	return files, fd
+}
+
+// startGreeter starts an in-process gRPC server implementing the greeter with dynamic messages,
+// with server reflection enabled.
+func startGreeter(t *testing.T, files *protoregistry.Files) string {
+	t.Helper()
+
+	desc, err := files.FindDescriptorByName("test.Greeter")
+	require.NoError(t, err)
+	method := desc.(protoreflect.ServiceDescriptor).Methods().ByName("SayHello")
+
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Greeter",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "SayHello",
+				Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+					in := dynamicpb.NewMessage(method.Input())
+					if err := dec(in); err != nil {
+						return nil, err
+					}
+
+					name := in.Get(method.Input().Fields().ByName("name")).String()
+					if name == "" {
+						return nil, status.Error(codes.InvalidArgument, "name is required")
+					}
+					times := int(in.Get(method.Input().Fields().ByName("times")).Int())
+					if times == 0 {
+						times = 1
+					}
+
+					message := strings.TrimSpace(strings.Repeat("Hello "+name+" ", times))
+					if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-tenant")) > 0 {
+						message = fmt.Sprintf("%s from %s", message, md.Get("x-tenant")[0])
+					}
+					_ = grpc.SetHeader(ctx, metadata.Pairs("x-served-by", "greeter"))
+
+					out := dynamicpb.NewMessage(method.Output())
+					out.Set(method.Output().Fields().ByName("message"), protoreflect.ValueOfString(message))
+					return out, nil
+				},
+			},
+		},
+	}, struct{}{})
+
+	reflectionpb.RegisterServerReflectionServer(srv, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           srv,
+		DescriptorResolver: files,
+	}))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
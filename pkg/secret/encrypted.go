@@ -0,0 +1,100 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// KeySize is the size of the AES-256 keys of encrypted secrets files.
+const KeySize = 32
+
+// EncryptedFileProvider reads secrets from a file holding a JSON object of keys to secrets, encrypted with
+// AES-256-GCM by Encrypt. The file is read on every lookup, so updates are picked up once cached secrets
+// expire.
+type EncryptedFileProvider struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileProvider creates a provider for the encrypted file at path. key is the base64 encoded
+// 32 byte key.
+func NewEncryptedFileProvider(path, key string) (*EncryptedFileProvider, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileProvider{path: path, aead: aead}, nil
+}
+
+func (p *EncryptedFileProvider) Secret(_ context.Context, key string) (string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	plaintext, err := open(p.aead, data)
+	if err != nil {
+		return "", err
+	}
+
+	var secrets map[string]string
+	if err = json.Unmarshal(plaintext, &secrets); err != nil {
+		return "", errors.New("secrets file is not a JSON object of strings")
+	}
+	val, ok := secrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", key)
+	}
+	return val, nil
+}
+
+// Encrypt encrypts a JSON object of keys to secrets for an EncryptedFileProvider. key is the base64
+// encoded 32 byte key.
+func Encrypt(key string, plaintext []byte) ([]byte, error) {
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, errors.New("secrets must be a JSON object of strings")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("secrets file is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt secrets file: wrong key or corrupted file")
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != KeySize {
+		return nil, fmt.Errorf("secrets key must be %d base64 encoded bytes", KeySize)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
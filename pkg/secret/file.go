@@ -0,0 +1,38 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DockerSecretsDir is where Docker mounts secrets.
+const DockerSecretsDir = "/run/secrets"
+
+// FileProvider reads secrets from files, without trailing newlines. With a directory, keys are the names of
+// files within it, as in Docker and Kubernetes secret mounts. Without one, keys are file paths.
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Secret(_ context.Context, key string) (string, error) {
+	path := key
+	if p.dir != "" {
+		if !filepath.IsLocal(key) {
+			return "", fmt.Errorf("invalid secret key %q: must be a file name within %s", key, p.dir)
+		}
+		path = filepath.Join(p.dir, key)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxSecretSize bounds the responses read by an HTTPProvider.
+const maxSecretSize = 1 << 20
+
+type HTTPOption func(*HTTPProvider)
+
+// HTTPProvider fetches secrets from an HTTP service, such as a vault, with GET <url>/<key>. The response
+// body is the secret or, with a field, a JSON document holding it at the field's dot path.
+type HTTPProvider struct {
+	url        string
+	headers    map[string]string
+	field      string
+	httpClient *http.Client
+}
+
+func NewHTTPProvider(baseURL string, opts ...HTTPOption) *HTTPProvider {
+	p := &HTTPProvider{
+		url:        strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// WithHttpClient sets the client used to fetch secrets.
+func WithHttpClient(client *http.Client) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.httpClient = client
+	}
+}
+
+// WithHeaders sets headers sent with every request, e.g. the token of the vault.
+func WithHeaders(headers map[string]string) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.headers = headers
+	}
+}
+
+// WithField reads the secret from a dot path in the JSON response, e.g. "data.value".
+func WithField(field string) HTTPOption {
+	return func(p *HTTPProvider) {
+		p.field = field
+	}
+}
+
+func (p *HTTPProvider) Secret(ctx context.Context, key string) (string, error) {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secret request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secret request failed: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	if p.field == "" {
+		return strings.TrimRight(string(body), "\r\n"), nil
+	}
+
+	var doc any
+	if err = json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("secret response is not JSON")
+	}
+	for _, name := range strings.Split(p.field, ".") {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("secret field %q not found", p.field)
+		}
+		if doc, ok = obj[name]; !ok {
+			return "", fmt.Errorf("secret field %q not found", p.field)
+		}
+	}
+	val, ok := doc.(string)
+	if !ok {
+		return "", fmt.Errorf("secret field %q is not a string", p.field)
+	}
+	return val, nil
+}
@@ -0,0 +1,56 @@
+package secret
+
+import (
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"time"
+)
+
+const (
+	TypeFile       = "file"
+	TypeDocker     = "docker"
+	TypeKubernetes = "kubernetes"
+	TypeEncrypted  = "encrypted"
+	TypeHTTP       = "http"
+)
+
+// Load creates the provider configured by cfg and returns it with its TTL. opts apply to http providers.
+func Load(cfg types.SecretProvider, opts ...HTTPOption) (Provider, time.Duration, error) {
+	var ttl time.Duration
+	if cfg.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(cfg.TTL); err != nil {
+			return nil, 0, fmt.Errorf("invalid secret ttl: %w", err)
+		}
+	}
+
+	switch cfg.Type {
+	case TypeFile:
+		return NewFileProvider(cfg.Path), ttl, nil
+	case TypeDocker:
+		dir := cfg.Path
+		if dir == "" {
+			dir = DockerSecretsDir
+		}
+		return NewFileProvider(dir), ttl, nil
+	case TypeKubernetes:
+		if cfg.Path == "" {
+			return nil, 0, fmt.Errorf("%s secrets require the path of the secret volume", TypeKubernetes)
+		}
+		return NewFileProvider(cfg.Path), ttl, nil
+	case TypeEncrypted:
+		if cfg.Path == "" {
+			return nil, 0, fmt.Errorf("%s secrets require the path of the secrets file", TypeEncrypted)
+		}
+		p, err := NewEncryptedFileProvider(cfg.Path, cfg.Key)
+		return p, ttl, err
+	case TypeHTTP:
+		if cfg.URL == "" {
+			return nil, 0, fmt.Errorf("%s secrets require a url", TypeHTTP)
+		}
+		opts = append(opts, WithHeaders(cfg.Headers), WithField(cfg.Field))
+		return NewHTTPProvider(cfg.URL, opts...), ttl, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported secret provider type: %s", cfg.Type)
+	}
+}
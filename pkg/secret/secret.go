@@ -0,0 +1,127 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long secrets are cached when their provider sets no TTL.
+const DefaultTTL = 5 * time.Minute
+
+// Provider fetches secrets by key. Errors must not contain secret values.
+type Provider interface {
+	Secret(ctx context.Context, key string) (string, error)
+}
+
+// Store resolves secrets from named providers, caching each secret for the TTL of its provider.
+type Store struct {
+	mu        sync.RWMutex
+	providers map[string]*cache
+}
+
+type cache struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+type entry struct {
+	value   string
+	expires time.Time
+}
+
+func NewStore() *Store {
+	return &Store{providers: make(map[string]*cache)}
+}
+
+// Register adds a provider under name, replacing any provider of the same name. A ttl of zero or less
+// uses DefaultTTL.
+func (s *Store) Register(name string, p Provider, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers[name] = &cache{provider: p, ttl: ttl, entries: make(map[string]entry)}
+}
+
+// Has reports whether a provider is registered under name.
+func (s *Store) Has(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.providers[name]
+	return ok
+}
+
+// Get returns the secret key of the named provider. Secrets are refreshed once their TTL expires; when the
+// refresh fails, the expired value is used and the failure is logged.
+func (s *Store) Get(ctx context.Context, name, key string) (string, error) {
+	s.mu.RLock()
+	c, ok := s.providers[name]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider: %s", name)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	val, err := c.provider.Secret(ctx, key)
+	if err != nil {
+		if !ok {
+			return "", fmt.Errorf("secret %s %s: %w", name, key, err)
+		}
+		slog.Warn("failed to refresh secret, using cached value",
+			slog.Group("secret",
+				slog.String("provider", name),
+				slog.String("key", key),
+			),
+			slog.String("error", err.Error()),
+		)
+		return cached.value, nil
+	}
+
+	c.mu.Lock()
+	c.entries[key] = entry{value: val, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return val, nil
+}
+
+// Func returns the placeholder function resolving {{secret <provider> <key>}}. A nil store fails every
+// lookup.
+func (s *Store) Func(ctx context.Context) placeholder.Func {
+	return func(arg string) (string, error) {
+		name, key, ok := strings.Cut(strings.TrimSpace(arg), " ")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return "", fmt.Errorf("secret placeholders take a provider and a key")
+		}
+		if s == nil {
+			return "", fmt.Errorf("no secret store configured")
+		}
+		return s.Get(ctx, name, key)
+	}
+}
+
+// Record wraps fn, appending every value it resolves to values, e.g. to mask them in the logs of a call.
+func Record(fn placeholder.Func, values *[]string) placeholder.Func {
+	return func(arg string) (string, error) {
+		val, err := fn(arg)
+		if err == nil {
+			*values = append(*values, val)
+		}
+		return val, err
+	}
+}
@@ -0,0 +1,200 @@
+package secret_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type countingProvider struct {
+	calls int
+	value string
+	err   error
+}
+
+func (p *countingProvider) Secret(_ context.Context, key string) (string, error) {
+	p.calls++
+	return p.value + key, p.err
+}
+
+func TestStore_CachesForTTL(t *testing.T) {
+	p := &countingProvider{value: "v1-"}
+	store := secret.NewStore()
+	store.Register("vault", p, 20*time.Millisecond)
+
+	for range 3 {
+		val, err := store.Get(context.Background(), "vault", "token")
+		require.NoError(t, err)
+		assert.Equal(t, "v1-token", val)
+	}
+	assert.Equal(t, 1, p.calls)
+
+	time.Sleep(30 * time.Millisecond)
+	p.value = "v2-"
+	val, err := store.Get(context.Background(), "vault", "token")
+	require.NoError(t, err)
+	assert.Equal(t, "v2-token", val)
+	assert.Equal(t, 2, p.calls)
+}
+
+func TestStore_KeepsExpiredValueWhenRefreshFails(t *testing.T) {
+	p := &countingProvider{value: "v1-"}
+	store := secret.NewStore()
+	store.Register("vault", p, time.Millisecond)
+
+	_, err := store.Get(context.Background(), "vault", "token")
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	p.err = errors.New("vault unavailable")
+	val, err := store.Get(context.Background(), "vault", "token")
+	require.NoError(t, err)
+	assert.Equal(t, "v1-token", val)
+
+	_, err = store.Get(context.Background(), "vault", "other")
+	assert.ErrorContains(t, err, "secret vault other: vault unavailable")
+}
+
+func TestStore_Func(t *testing.T) {
+	store := secret.NewStore()
+	store.Register("vault", &countingProvider{value: "s-"}, 0)
+	fn := store.Func(context.Background())
+
+	val, err := fn("vault  token")
+	require.NoError(t, err)
+	assert.Equal(t, "s-token", val)
+
+	_, err = fn("vault")
+	assert.ErrorContains(t, err, "take a provider and a key")
+	_, err = fn("missing token")
+	assert.ErrorContains(t, err, "unknown secret provider: missing")
+
+	var nilStore *secret.Store
+	_, err = nilStore.Func(context.Background())("vault token")
+	assert.ErrorContains(t, err, "no secret store configured")
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("secret\n"), 0600))
+
+	val, err := secret.NewFileProvider(dir).Secret(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", val)
+
+	_, err = secret.NewFileProvider(dir).Secret(context.Background(), "../token")
+	assert.ErrorContains(t, err, "must be a file name within")
+
+	val, err = secret.NewFileProvider("").Secret(context.Background(), filepath.Join(dir, "token"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", val)
+}
+
+func TestEncryptedFileProvider(t *testing.T) {
+	key := newKey(t)
+	data, err := secret.Encrypt(key, []byte(`{"gitlab": "glpat-123"}`))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "glpat-123")
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	p, err := secret.NewEncryptedFileProvider(path, key)
+	require.NoError(t, err)
+	val, err := p.Secret(context.Background(), "gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-123", val)
+
+	_, err = p.Secret(context.Background(), "jira")
+	assert.ErrorContains(t, err, `secret "jira" not found`)
+
+	p, err = secret.NewEncryptedFileProvider(path, newKey(t))
+	require.NoError(t, err)
+	_, err = p.Secret(context.Background(), "gitlab")
+	assert.ErrorContains(t, err, "wrong key")
+
+	_, err = secret.NewEncryptedFileProvider(path, "short")
+	assert.ErrorContains(t, err, "must be 32 base64 encoded bytes")
+	_, err = secret.Encrypt(key, []byte(`["not", "an", "object"]`))
+	assert.ErrorContains(t, err, "JSON object of strings")
+}
+
+func TestHTTPProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/gitlab":
+			_, _ = w.Write([]byte(`{"data": {"token": "glpat-123"}}`))
+		case "/v1/plain":
+			_, _ = w.Write([]byte("plain-secret\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	headers := map[string]string{"X-Vault-Token": "root"}
+
+	p := secret.NewHTTPProvider(ts.URL+"/v1/", secret.WithHeaders(headers), secret.WithField("data.token"))
+	val, err := p.Secret(context.Background(), "secret/gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-123", val)
+
+	val, err = secret.NewHTTPProvider(ts.URL+"/v1", secret.WithHeaders(headers)).Secret(context.Background(), "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-secret", val)
+
+	_, err = p.Secret(context.Background(), "missing")
+	assert.ErrorContains(t, err, "404 Not Found")
+	_, err = secret.NewHTTPProvider(ts.URL).Secret(context.Background(), "plain")
+	assert.ErrorContains(t, err, "403 Forbidden")
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		cfg types.SecretProvider
+		err string
+	}{
+		{cfg: types.SecretProvider{Type: secret.TypeFile}},
+		{cfg: types.SecretProvider{Type: secret.TypeDocker, TTL: "1m"}},
+		{cfg: types.SecretProvider{Type: secret.TypeKubernetes}, err: "require the path of the secret volume"},
+		{cfg: types.SecretProvider{Type: secret.TypeEncrypted, Path: "secrets.enc"}, err: "must be 32 base64 encoded bytes"},
+		{cfg: types.SecretProvider{Type: secret.TypeHTTP}, err: "require a url"},
+		{cfg: types.SecretProvider{Type: secret.TypeFile, TTL: "soon"}, err: "invalid secret ttl"},
+		{cfg: types.SecretProvider{Type: "aws"}, err: "unsupported secret provider type: aws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cfg.Type, func(t *testing.T) {
+			p, _, err := secret.Load(tt.cfg)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, p)
+		})
+	}
+}
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, secret.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
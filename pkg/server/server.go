@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"github.com/AdamShannag/api-mcp-server/internal/auth"
+	"github.com/AdamShannag/api-mcp-server/internal/loader"
+	"github.com/AdamShannag/api-mcp-server/internal/middleware"
+	"github.com/AdamShannag/api-mcp-server/internal/monitoring"
+	"github.com/AdamShannag/api-mcp-server/pkg/plugin"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/resolver"
+	"github.com/AdamShannag/api-mcp-server/pkg/secret"
+	"github.com/AdamShannag/api-mcp-server/pkg/tool"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"net/http"
+)
+
+const (
+	Name    = "API MCP Server"
+	Version = "0.2.0"
+)
+
+type Option func(*Server)
+
+// Server is an embeddable MCP server exposing API tools. Tools are registered from types.Tool values,
+// Go handler funcs or config files, and the server is served over stdio or mounted as an http.Handler.
+type Server struct {
+	name        string
+	version     string
+	executor    request.Executor
+	argResolver resolver.ArgResolver
+	toolOpts    []tool.Option
+	serverOpts  []mcpserver.ServerOption
+	middlewares []mcpserver.ToolHandlerMiddleware
+	hooks       *mcpserver.Hooks
+	sseOpts     []mcpserver.SSEOption
+	toolsFiles  []string
+	loaderOpts  []loader.Option
+	auth        *auth.Authenticator
+	monitoring  bool
+
+	mcpServer *mcpserver.MCPServer
+	sseServer *mcpserver.SSEServer
+	manager   *tool.Manager
+	loader    *loader.Loader
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{
+		name:    Name,
+		version: Version,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.executor == nil {
+		s.executor = request.NewExecutor()
+	}
+
+	toolOpts := s.toolOpts
+	if s.argResolver != nil {
+		toolOpts = append(toolOpts, tool.WithArgResolver(s.argResolver))
+	}
+	s.manager = tool.NewManager(s.executor, toolOpts...)
+
+	options := []mcpserver.ServerOption{
+		mcpserver.WithLogging(),
+		mcpserver.WithRecovery(),
+		mcpserver.WithToolCapabilities(true),
+	}
+	if s.monitoring {
+		if s.hooks == nil {
+			s.hooks = &mcpserver.Hooks{}
+		}
+		monitoring.AddHooks(s.hooks)
+	}
+	if s.hooks != nil {
+		options = append(options, mcpserver.WithHooks(s.hooks))
+	}
+	if s.auth != nil {
+		options = append(options, mcpserver.WithToolHandlerMiddleware(s.auth.Middleware()))
+	}
+	if s.monitoring {
+		options = append(options, mcpserver.WithToolHandlerMiddleware(middleware.LoggingMiddleware))
+	}
+	for _, mw := range s.middlewares {
+		options = append(options, mcpserver.WithToolHandlerMiddleware(mw))
+	}
+	options = append(options, s.serverOpts...)
+
+	s.mcpServer = mcpserver.NewMCPServer(s.name, s.version, options...)
+	s.manager.AddContinuationTool(s.mcpServer)
+
+	sseOpts := s.sseOpts
+	if s.auth != nil {
+		sseOpts = append(sseOpts, mcpserver.WithSSEContextFunc(s.auth.FromRequest))
+	}
+	s.sseServer = mcpserver.NewSSEServer(s.mcpServer, sseOpts...)
+	s.loader = loader.New(s.toolsFiles, s.loaderOpts...)
+
+	return s
+}
+
+// WithImplementation sets the name and version reported to clients.
+func WithImplementation(name, version string) Option {
+	return func(s *Server) {
+		s.name = name
+		s.version = version
+	}
+}
+
+// WithExecutor sets the executor running tool requests. It defaults to request.NewExecutor().
+func WithExecutor(executor request.Executor) Option {
+	return func(s *Server) {
+		s.executor = executor
+	}
+}
+
+// WithArgResolver sets the resolver of tool args. It defaults to resolver.NewDefaultTypeResolverRegistry().
+func WithArgResolver(r resolver.ArgResolver) Option {
+	return func(s *Server) {
+		s.argResolver = r
+	}
+}
+
+// WithToolOptions sets additional options of the tool manager.
+func WithToolOptions(opts ...tool.Option) Option {
+	return func(s *Server) {
+		s.toolOpts = append(s.toolOpts, opts...)
+	}
+}
+
+// WithMiddleware adds tool handler middlewares, applied in the given order.
+func WithMiddleware(middlewares ...mcpserver.ToolHandlerMiddleware) Option {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithHooks sets the hooks called on session and request lifecycle events.
+func WithHooks(hooks *mcpserver.Hooks) Option {
+	return func(s *Server) {
+		s.hooks = hooks
+	}
+}
+
+// WithServerOptions sets additional options of the underlying MCP server.
+func WithServerOptions(opts ...mcpserver.ServerOption) Option {
+	return func(s *Server) {
+		s.serverOpts = append(s.serverOpts, opts...)
+	}
+}
+
+// WithSSEOptions sets the options of the SSE transport returned by Handler, e.g. its base path.
+func WithSSEOptions(opts ...mcpserver.SSEOption) Option {
+	return func(s *Server) {
+		s.sseOpts = append(s.sseOpts, opts...)
+	}
+}
+
+// WithToolsFiles adds config files, directories or glob patterns to load tools from with LoadTools.
+func WithToolsFiles(paths ...string) Option {
+	return func(s *Server) {
+		s.toolsFiles = append(s.toolsFiles, paths...)
+	}
+}
+
+// WithPluginOptions sets the options used to load the plugins of config files.
+func WithPluginOptions(opts ...plugin.Option) Option {
+	return func(s *Server) {
+		s.loaderOpts = append(s.loaderOpts, loader.WithPluginOptions(opts...))
+	}
+}
+
+// WithSecrets sets the store the secret providers of config files are registered with. It should be the
+// store of the executor.
+func WithSecrets(store *secret.Store, opts ...secret.HTTPOption) Option {
+	return func(s *Server) {
+		s.loaderOpts = append(s.loaderOpts, loader.WithSecrets(store, opts...))
+	}
+}
+
+// WithAPIKey requires tool calls to carry the key as a Bearer token in their Authorization header. The
+// header is read by the transport returned by Handler, so in-process and stdio clients are rejected.
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.auth = auth.NewAuthenticator("sse", key)
+	}
+}
+
+// WithMonitoring logs tool calls, sessions and errors, and records them in the metrics served by
+// MetricsHandler.
+func WithMonitoring() Option {
+	return func(s *Server) {
+		s.monitoring = true
+	}
+}
+
+// AddTool registers a tool executing an API request.
+func (s *Server) AddTool(t types.Tool) error {
+	return s.manager.AddTool(s.mcpServer, t)
+}
+
+// AddTools registers the tools in order, stopping at the first error.
+func (s *Server) AddTools(tools ...types.Tool) error {
+	for _, t := range tools {
+		if err := s.AddTool(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddHandler registers a tool implemented by a Go function.
+func (s *Server) AddHandler(name, description string, args []types.Arg, handler tool.HandlerFunc) {
+	s.manager.AddHandler(s.mcpServer, name, description, args, handler)
+}
+
+// LoadTools loads and registers the tools of the config files set with WithToolsFiles, with their plugins
+// and secret providers. Nothing is loaded when the configs fail validation.
+func (s *Server) LoadTools() error {
+	return s.loader.Load(s.manager, s.mcpServer)
+}
+
+// ValidateTools checks the config files set with WithToolsFiles without loading them, reporting every
+// problem found.
+func (s *Server) ValidateTools() error {
+	return s.loader.Validate()
+}
+
+// Close releases the plugins loaded by LoadTools.
+func (s *Server) Close(ctx context.Context) {
+	s.loader.Close(ctx)
+}
+
+// MCPServer returns the underlying MCP server.
+func (s *Server) MCPServer() *mcpserver.MCPServer {
+	return s.mcpServer
+}
+
+// Manager returns the tool manager, e.g. to register arg types and request kinds.
+func (s *Server) Manager() *tool.Manager {
+	return s.manager
+}
+
+// Handler returns the SSE transport as an http.Handler serving <basePath>/sse and <basePath>/message,
+// to mount in an existing mux.
+func (s *Server) Handler() http.Handler {
+	return s.SSEServer()
+}
+
+// SSEServer returns the SSE transport served by Handler, which can also be started and shut down on its own.
+func (s *Server) SSEServer() *mcpserver.SSEServer {
+	return s.sseServer
+}
+
+// MetricsHandler returns an http.Handler serving the metrics recorded with WithMonitoring in the
+// Prometheus format.
+func (s *Server) MetricsHandler() http.Handler {
+	return monitoring.Handler()
+}
+
+// ServeStdio serves the server over stdin and stdout until it is closed.
+func (s *Server) ServeStdio() error {
+	return mcpserver.ServeStdio(s.mcpServer)
+}
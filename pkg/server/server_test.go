@@ -0,0 +1,228 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/server"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServer_Tools(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer api.Close()
+
+	var called []string
+	s := server.New(
+		server.WithImplementation("embedded", "1.0.0"),
+		server.WithMiddleware(func(next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+			return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				called = append(called, req.Params.Name)
+				return next(ctx, req)
+			}
+		}),
+	)
+
+	require.NoError(t, s.AddTools(types.Tool{
+		Name: "GetUser",
+		Args: []types.Arg{{Name: "id", Type: "string", Required: true}},
+		Request: types.Request{
+			Host:       strings.TrimPrefix(api.URL, "http://"),
+			Endpoint:   "/users/:id",
+			Method:     http.MethodGet,
+			PathParams: []string{"id"},
+		},
+	}))
+
+	s.AddHandler("Greet", "Greets someone", []types.Arg{{Name: "name", Type: "string", Required: true}},
+		func(_ context.Context, args map[string]string) (string, error) {
+			return "Hello " + args["name"], nil
+		},
+	)
+
+	c := inProcessClient(t, s)
+
+	text := callTool(t, c, "GetUser", map[string]any{"id": "42"})
+	assert.Contains(t, text, `\"path\":\"/users/42\"`)
+
+	text = callTool(t, c, "Greet", map[string]any{"name": "Ada"})
+	assert.Equal(t, "Hello Ada", text)
+
+	assert.Equal(t, []string{"GetUser", "Greet"}, called)
+}
+
+func TestServer_HandlerStatusError(t *testing.T) {
+	s := server.New()
+	s.AddHandler("Fail", "Always fails", nil, func(context.Context, map[string]string) (string, error) {
+		return "", &request.StatusError{Response: types.ErrorResponse{StatusCode: 404, Status: "404 Not Found"}}
+	})
+
+	c := inProcessClient(t, s)
+
+	var req mcp.CallToolRequest
+	req.Params.Name = "Fail"
+	result, err := c.CallTool(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `"status_code":404`)
+}
+
+func TestServer_CustomExecutor(t *testing.T) {
+	s := server.New(server.WithExecutor(executorFunc(func(_ context.Context, req types.Request, args map[string]string) (string, error) {
+		return req.Endpoint + "?" + args["q"], nil
+	})))
+	require.NoError(t, s.AddTool(types.Tool{
+		Name:    "Search",
+		Args:    []types.Arg{{Name: "q", Type: "string"}},
+		Request: types.Request{Endpoint: "/search"},
+	}))
+
+	assert.Equal(t, "/search?go", callTool(t, inProcessClient(t, s), "Search", map[string]any{"q": "go"}))
+}
+
+func TestServer_Handler(t *testing.T) {
+	s := server.New(server.WithSSEOptions(mcpserver.WithStaticBasePath("/mcp")))
+	s.AddHandler("Ping", "Pings", nil, func(context.Context, map[string]string) (string, error) {
+		return "pong", nil
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp/", s.Handler())
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := client.NewSSEMCPClient(srv.URL + "/mcp/sse")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	require.NoError(t, c.Start(context.Background()))
+	initialize(t, c)
+
+	assert.Equal(t, "pong", callTool(t, c, "Ping", nil))
+}
+
+func TestServer_HandlerOnce(t *testing.T) {
+	s := server.New()
+
+	assert.Same(t, s.SSEServer(), s.Handler())
+}
+
+func TestServer_APIKey(t *testing.T) {
+	s := server.New(server.WithAPIKey("s3cret"))
+	s.AddHandler("Ping", "Pings", nil, func(context.Context, map[string]string) (string, error) {
+		return "pong", nil
+	})
+
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	call := func(headers map[string]string) *mcp.CallToolResult {
+		c, err := client.NewSSEMCPClient(srv.URL+"/sse", client.WithHeaders(headers))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = c.Close() })
+		require.NoError(t, c.Start(context.Background()))
+		initialize(t, c)
+
+		var req mcp.CallToolRequest
+		req.Params.Name = "Ping"
+		result, err := c.CallTool(context.Background(), req)
+		if err != nil {
+			return nil
+		}
+		return result
+	}
+
+	result := call(map[string]string{"Authorization": "Bearer s3cret"})
+	require.NotNil(t, result)
+	assert.Equal(t, "pong", result.Content[0].(mcp.TextContent).Text)
+
+	assert.Nil(t, call(map[string]string{"Authorization": "Bearer wrong"}))
+	assert.Nil(t, call(nil))
+}
+
+func TestServer_LoadTools(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tools.json"), []byte(`{
+  "prefix": "api_",
+  "tools": [{"name": "Ping", "request": {"host": "example.com", "endpoint": "/ping"}}]
+}`), 0644))
+
+	s := server.New(
+		server.WithExecutor(request.NewExecutor(request.WithDryRun())),
+		server.WithToolsFiles(dir),
+	)
+	t.Cleanup(func() { s.Close(context.Background()) })
+	require.NoError(t, s.ValidateTools())
+	require.NoError(t, s.LoadTools())
+
+	assert.Contains(t, callTool(t, inProcessClient(t, s), "api_Ping", nil), "example.com/ping")
+
+	s = server.New(server.WithToolsFiles(filepath.Join(dir, "missing.json")))
+	assert.Error(t, s.LoadTools())
+}
+
+func TestServer_Monitoring(t *testing.T) {
+	s := server.New(server.WithMonitoring())
+	s.AddHandler("Ping", "Pings", nil, func(context.Context, map[string]string) (string, error) {
+		return "pong", nil
+	})
+
+	assert.Equal(t, "pong", callTool(t, inProcessClient(t, s), "Ping", nil))
+
+	rec := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `api_mcp_server_tool_invocations_total{tool="Ping"}`)
+}
+
+type executorFunc func(context.Context, types.Request, map[string]string) (string, error)
+
+func (f executorFunc) Execute(ctx context.Context, req types.Request, args map[string]string) (string, error) {
+	return f(ctx, req, args)
+}
+
+func inProcessClient(t *testing.T, s *server.Server) *client.Client {
+	t.Helper()
+
+	c, err := client.NewInProcessClient(s.MCPServer())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	require.NoError(t, c.Start(context.Background()))
+	initialize(t, c)
+	return c
+}
+
+func initialize(t *testing.T, c *client.Client) {
+	t.Helper()
+
+	var req mcp.InitializeRequest
+	req.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	_, err := c.Initialize(context.Background(), req)
+	require.NoError(t, err)
+}
+
+func callTool(t *testing.T, c *client.Client, name string, args map[string]any) string {
+	t.Helper()
+
+	var req mcp.CallToolRequest
+	req.Params.Name = name
+	req.Params.Arguments = args
+
+	result, err := c.CallTool(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, result.IsError, result.Content)
+	return result.Content[0].(mcp.TextContent).Text
+}
@@ -0,0 +1,144 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/sync/errgroup"
+	"log/slog"
+	"time"
+)
+
+const (
+	batchToolSuffix         = "Batch"
+	defaultBatchConcurrency = 4
+	defaultBatchMaxItems    = 100
+)
+
+type batchItemResult struct {
+	Index   int  `json:"index"`
+	IsError bool `json:"isError,omitempty"`
+	Result  any  `json:"result"`
+}
+
+// BatchName returns the name of the batch variant of a tool.
+func BatchName(tool string) string {
+	return tool + batchToolSuffix
+}
+
+// addBatchTool registers a variant of the tool taking an array of argument sets, executed concurrently.
+// The argument sets follow the input schema of single, the tool registered for one call. Items call the
+// handler of the tool directly: the server middlewares, like authentication, logging and rate limiting, see
+// the batch call once, and each item is logged here.
+func (tm *Manager) addBatchTool(mcpServer *server.MCPServer, tool types.Tool, single mcp.Tool, handler server.ToolHandlerFunc) {
+	itemSchema := map[string]any{
+		"type":       "object",
+		"properties": single.InputSchema.Properties,
+	}
+	if len(single.InputSchema.Required) > 0 {
+		itemSchema["required"] = single.InputSchema.Required
+	}
+
+	name := BatchName(tool.Name)
+	tm.setSensitiveKeys(name, tool.Args)
+	t := mcp.NewTool(name,
+		mcp.WithDescription(fmt.Sprintf("Batch variant of %s: runs it once per item and returns all results. %s", tool.Name, tool.Description)),
+		mcp.WithArray("items",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Argument sets, one per call to %s", tool.Name)),
+			mcp.Items(itemSchema),
+		),
+	)
+	mcpServer.AddTool(t, tm.batchHandler(tool, handler))
+
+	slog.Debug("batch tool registered", slog.String("tool", name))
+}
+
+func (tm *Manager) batchHandler(tool types.Tool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	concurrency := tool.Batch.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	maxItems := tool.Batch.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		items, ok := req.GetArguments()["items"].([]any)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid argument %q: expected an array", "items")), nil
+		}
+		if len(items) > maxItems {
+			return mcp.NewToolResultError(fmt.Sprintf("too many items: %d, maximum is %d", len(items), maxItems)), nil
+		}
+
+		results := make([]batchItemResult, len(items))
+
+		var g errgroup.Group
+		g.SetLimit(concurrency)
+		for i, item := range items {
+			g.Go(func() error {
+				results[i] = tm.runBatchItem(ctx, tool.Name, handler, i, item)
+				return nil
+			})
+		}
+		_ = g.Wait()
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func (tm *Manager) runBatchItem(ctx context.Context, name string, handler server.ToolHandlerFunc, index int, item any) batchItemResult {
+	args, ok := item.(map[string]any)
+	if !ok {
+		return batchItemResult{Index: index, IsError: true, Result: "item must be an object"}
+	}
+
+	var itemReq mcp.CallToolRequest
+	itemReq.Params.Name = name
+	itemReq.Params.Arguments = args
+
+	start := time.Now()
+	result, err := handler(ctx, itemReq)
+	if err != nil {
+		slog.ErrorContext(ctx, "batch item failed",
+			slog.String("tool", name),
+			slog.Int("index", index),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("error", err.Error()),
+		)
+		return batchItemResult{Index: index, IsError: true, Result: err.Error()}
+	}
+
+	slog.InfoContext(ctx, "batch item completed",
+		slog.String("tool", name),
+		slog.Int("index", index),
+		slog.Duration("duration", time.Since(start)),
+		slog.Bool("isError", result.IsError),
+	)
+	return batchItemResult{Index: index, IsError: result.IsError, Result: resultContent(result)}
+}
+
+// resultContent returns the text of a tool result, decoded when it holds JSON.
+func resultContent(result *mcp.CallToolResult) any {
+	if len(result.Content) == 0 {
+		return nil
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		return nil
+	}
+	var parsed any
+	if err := json.Unmarshal([]byte(text.Text), &parsed); err == nil {
+		return parsed
+	}
+	return text.Text
+}
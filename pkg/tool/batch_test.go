@@ -0,0 +1,118 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManager_BatchHandler(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	exec := executorFunc(func(_ context.Context, _ types.Request, args map[string]string) (string, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if args["id"] == "3" {
+			return "", errors.New("boom")
+		}
+		return response(200, `{"id":`+args["id"]+`}`), nil
+	})
+
+	mgr := NewManager(exec)
+	tool := types.Tool{
+		Name:  "GetTodo",
+		Args:  []types.Arg{{Name: "id", Type: "int", Required: true}},
+		Batch: &types.Batch{Concurrency: 2},
+	}
+	handler := mgr.batchHandler(tool, mgr.toolHandlerFactory(tool))
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{
+		"items": []any{
+			map[string]any{"id": 1},
+			map[string]any{"id": 2},
+			map[string]any{"id": 3},
+			map[string]any{},
+			"not-an-object",
+		},
+	}
+
+	resp, err := handler(context.Background(), req)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsError)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+
+	var results []batchItemResult
+	assert.NoError(t, json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &results))
+	assert.Len(t, results, 5)
+
+	assert.Equal(t, 0, results[0].Index)
+	assert.False(t, results[0].IsError)
+	assert.Equal(t, `{"id":1}`, results[0].Result.(map[string]any)["body"])
+
+	assert.False(t, results[1].IsError)
+
+	assert.True(t, results[2].IsError)
+	assert.Contains(t, results[2].Result, "boom")
+
+	assert.True(t, results[3].IsError)
+	assert.Contains(t, results[3].Result, "invalid argument")
+
+	assert.True(t, results[4].IsError)
+	assert.Equal(t, "item must be an object", results[4].Result)
+}
+
+func TestManager_BatchHandler_LogsItems(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	exec := executorFunc(func(_ context.Context, _ types.Request, _ map[string]string) (string, error) {
+		return response(200, `{}`), nil
+	})
+	mgr := NewManager(exec)
+	tool := types.Tool{Name: "GetTodo", Batch: &types.Batch{Concurrency: 1}}
+	handler := mgr.batchHandler(tool, mgr.toolHandlerFactory(tool))
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"items": []any{map[string]any{}, map[string]any{}}}
+	_, err := handler(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `msg="batch item completed" tool=GetTodo index=0`)
+	assert.Contains(t, buf.String(), `msg="batch item completed" tool=GetTodo index=1`)
+}
+
+func TestManager_BatchHandler_InvalidItems(t *testing.T) {
+	mgr := NewManager(&mockExecutor{})
+	tool := types.Tool{Name: "GetTodo", Batch: &types.Batch{MaxItems: 1}}
+	handler := mgr.batchHandler(tool, mgr.toolHandlerFactory(tool))
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"items": "nope"}
+	resp, err := handler(context.Background(), req)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsError)
+
+	req.Params.Arguments = map[string]any{"items": []any{map[string]any{}, map[string]any{}}}
+	resp, err = handler(context.Background(), req)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsError)
+	assert.Contains(t, resp.Content[0].(mcp.TextContent).Text, "too many items")
+}
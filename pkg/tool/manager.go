@@ -2,20 +2,44 @@ package tool
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/hook"
+	"github.com/AdamShannag/api-mcp-server/pkg/netguard"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
 	"github.com/AdamShannag/api-mcp-server/pkg/request"
 	"github.com/AdamShannag/api-mcp-server/pkg/resolver"
 	"github.com/AdamShannag/api-mcp-server/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"log/slog"
+	"os"
+	"sync"
 )
 
+// ContinuationToolName is the name of the tool used to fetch the remainder of truncated responses.
+const ContinuationToolName = "FetchResponseContinuation"
+
 type Option func(*Manager)
 
+// HandlerFunc handles a call to a tool implemented in Go with its resolved args.
+type HandlerFunc func(ctx context.Context, args map[string]string) (string, error)
+
 type Manager struct {
-	executor    request.Executor
-	argResolver resolver.ArgResolver
+	executor      request.Executor
+	argResolver   resolver.ArgResolver
+	continuations *request.Continuations
+	redactor      *redact.Redactor
+	readOnly      bool
+
+	mu    sync.RWMutex
+	hooks map[string]toolHooks
+}
+
+type toolHooks struct {
+	preRequest   *hook.Hook
+	postResponse *hook.Hook
 }
 
 func NewManager(executor request.Executor, opts ...Option) *Manager {
@@ -37,15 +61,94 @@ func WithArgResolver(r resolver.ArgResolver) Option {
 	}
 }
 
-func (tm *Manager) AddTool(mcpServer *server.MCPServer, tool types.Tool) {
+// WithContinuations enables the continuation tool backed by the given store.
+func WithContinuations(c *request.Continuations) Option {
+	return func(m *Manager) {
+		m.continuations = c
+	}
+}
+
+// WithRedactor sets the names of the sensitive args of added tools as their keys in the redactor, masking
+// the fields with these names in the logs of their calls, see redact.WithTool. The values of sensitive args
+// are also masked by the log handler of the redactor in the records logged during their calls, see
+// redact.WithValues.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(m *Manager) {
+		m.redactor = r
+	}
+}
+
+// WithReadOnly skips adding tools with requests that may modify data, see request.ReadOnly.
+func WithReadOnly() Option {
+	return func(m *Manager) {
+		m.readOnly = true
+	}
+}
+
+// RegisterArgType adds a resolver for an arg type. It requires the arg resolver to be a TypeResolverRegistry.
+func (tm *Manager) RegisterArgType(argType string, r resolver.ArgResolver) error {
+	registry, ok := tm.argResolver.(*resolver.TypeResolverRegistry)
+	if !ok {
+		return fmt.Errorf("arg type %q: arg resolver does not support registering types", argType)
+	}
+	registry.Register(argType, r)
+	return nil
+}
+
+// ArgTypes returns the arg types known to the arg resolver, or nil when it is not a TypeResolverRegistry.
+func (tm *Manager) ArgTypes() []string {
+	if registry, ok := tm.argResolver.(*resolver.TypeResolverRegistry); ok {
+		return registry.Types()
+	}
+	return nil
+}
+
+// RegisterKind adds an executor for a request kind. It requires the executor to be a request.KindRegistry.
+func (tm *Manager) RegisterKind(kind string, delegate request.Executor) error {
+	registry, ok := tm.executor.(request.KindRegistry)
+	if !ok {
+		return fmt.Errorf("request kind %q: executor does not support registering kinds", kind)
+	}
+	registry.RegisterKind(kind, delegate)
+	return nil
+}
+
+func (tm *Manager) AddTool(mcpServer *server.MCPServer, tool types.Tool) error {
+	if tm.readOnly && !readOnly(tool) {
+		slog.Warn("tool skipped in read-only mode", slog.String("tool", tool.Name))
+		return nil
+	}
+
+	hooks, err := compileHooks(tool)
+	if err != nil {
+		return fmt.Errorf("tool %q: %w", tool.Name, err)
+	}
+	tm.setHooks(tool.Name, hooks)
+
 	baseOptions := []mcp.ToolOption{
 		mcp.WithDescription(tool.Description),
 	}
 
 	options := append(baseOptions, tm.toOptions(tool.Args)...)
+	tm.setSensitiveKeys(tool.Name, tool.Args)
+
+	if tool.Request.ArgTypes == nil {
+		tool.Request.ArgTypes = argTypes(tool.Args)
+	}
+	tool.Steps = append([]types.Step(nil), tool.Steps...)
+	for i := range tool.Steps {
+		if tool.Steps[i].Request.ArgTypes == nil {
+			tool.Steps[i].Request.ArgTypes = tool.Request.ArgTypes
+		}
+	}
 
 	t := mcp.NewTool(tool.Name, options...)
-	mcpServer.AddTool(t, tm.toolHandlerFactory(tool))
+	handler := tm.toolHandlerFactory(tool)
+	mcpServer.AddTool(t, handler)
+
+	if tool.Batch != nil {
+		tm.addBatchTool(mcpServer, tool, t, handler)
+	}
 
 	slog.Debug("tool registered",
 		slog.Group("tool",
@@ -53,6 +156,56 @@ func (tm *Manager) AddTool(mcpServer *server.MCPServer, tool types.Tool) {
 			slog.Int("args", len(tool.Args)),
 		),
 	)
+
+	return nil
+}
+
+// AddHandler registers a tool implemented by a Go function instead of a request. Its args are resolved
+// like those of request tools, and a returned request.StatusError is reported as a structured tool error.
+func (tm *Manager) AddHandler(mcpServer *server.MCPServer, name, description string, args []types.Arg, handler HandlerFunc) {
+	options := append([]mcp.ToolOption{mcp.WithDescription(description)}, tm.toOptions(args)...)
+	tm.setSensitiveKeys(name, args)
+	mcpServer.AddTool(mcp.NewTool(name, options...), tm.handler(name, args, handler))
+
+	slog.Debug("tool registered",
+		slog.Group("tool",
+			slog.String("name", name),
+			slog.Int("args", len(args)),
+		),
+	)
+}
+
+// AddContinuationTool registers the tool that fetches the next chunk of a truncated response.
+// It does nothing unless continuations are enabled.
+func (tm *Manager) AddContinuationTool(mcpServer *server.MCPServer) {
+	if tm.continuations == nil {
+		return
+	}
+
+	t := mcp.NewTool(ContinuationToolName,
+		mcp.WithDescription("Fetches the next chunk of a truncated tool response using its continuation token."),
+		mcp.WithString("token", mcp.Required(), mcp.Description("The continuation token from a truncated response")),
+	)
+	mcpServer.AddTool(t, tm.continuationHandler)
+}
+
+func (tm *Manager) continuationHandler(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	token, err := request.RequireString("token")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid argument %q: %v", "token", err)), nil
+	}
+
+	response, err := tm.continuations.Next(token)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return mcp.NewToolResultText(string(result)), nil
 }
 
 func (tm *Manager) toOptions(args []types.Arg) []mcp.ToolOption {
@@ -63,23 +216,167 @@ func (tm *Manager) toOptions(args []types.Arg) []mcp.ToolOption {
 	return options
 }
 
+// setSensitiveKeys sets the names of the sensitive args as the keys of the tool in the redactor.
+func (tm *Manager) setSensitiveKeys(tool string, args []types.Arg) {
+	if tm.redactor == nil {
+		return
+	}
+	var names []string
+	for _, arg := range args {
+		if arg.Sensitive {
+			names = append(names, arg.Name)
+		}
+	}
+	tm.redactor.SetToolKeys(tool, names...)
+}
+
+// readOnly reports whether the request of a tool, or each of its steps, only reads data.
+func readOnly(tool types.Tool) bool {
+	if len(tool.Steps) == 0 {
+		return request.ReadOnly(tool.Request)
+	}
+	for _, step := range tool.Steps {
+		if !request.ReadOnly(step.Request) {
+			return false
+		}
+	}
+	return true
+}
+
+func argTypes(args []types.Arg) map[string]string {
+	byName := make(map[string]string, len(args))
+	for _, arg := range args {
+		byName[arg.Name] = arg.Type
+	}
+	return byName
+}
+
 func (tm *Manager) toolHandlerFactory(tool types.Tool) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return tm.handler(tool.Name, tool.Args, func(ctx context.Context, args map[string]string) (string, error) {
+		return tm.execute(netguard.ContextWithHosts(ctx, tool.AllowedHosts), tool, args)
+	})
+}
+
+// handler resolves the args of a call and reports the result of run, or its error, as a tool result.
+func (tm *Manager) handler(name string, toolArgs []types.Arg, run HandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = redact.WithTool(ctx, name)
 		args := make(map[string]string)
+		passed := req.GetArguments()
 
-		for _, arg := range tool.Args {
-			val, err := tm.argResolver.Resolve(ctx, request, arg)
+		var omitted, sensitive []string
+		for _, arg := range toolArgs {
+			val, err := tm.argResolver.Resolve(ctx, req, arg)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("invalid argument %q: %v", arg.Name, err)), nil
 			}
 			args[arg.Name] = val
+			if arg.Sensitive {
+				sensitive = append(sensitive, val)
+			}
+			if _, ok := passed[arg.Name]; !ok && !arg.Required && arg.DefaultValue == nil {
+				omitted = append(omitted, arg.Name)
+			}
+		}
+		if len(omitted) > 0 {
+			ctx = request.WithOmittedArgs(ctx, omitted...)
+		}
+		if len(sensitive) > 0 {
+			// The values are masked wherever they end up in the logs of the call, e.g. in URLs.
+			ctx = redact.WithValues(ctx, sensitive...)
 		}
 
-		resp, err := tm.executor.Execute(ctx, tool.Request, args)
+		resp, err := run(ctx, args)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("request failed: %v", err)), err
+			var statusErr *request.StatusError
+			if errors.As(err, &statusErr) {
+				return statusErrorResult(statusErr)
+			}
+			// The failure is reported to the LLM as a tool error, not as a protocol error as well.
+			slog.ErrorContext(ctx, "tool request failed", slog.String("error", err.Error()))
+			return mcp.NewToolResultError(fmt.Sprintf("request failed: %v", err)), nil
 		}
 
 		return mcp.NewToolResultText(resp), nil
 	}
 }
+
+// statusErrorResult reports an upstream non-2xx response to the LLM as a structured tool error.
+func statusErrorResult(statusErr *request.StatusError) (*mcp.CallToolResult, error) {
+	result, err := json.Marshal(statusErr.Response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return mcp.NewToolResultError(string(result)), nil
+}
+
+// execute runs the tool request, or its steps, wrapped by the tool's pre-request and post-response hooks.
+// The pre-request hook runs before the request, or before each step.
+func (tm *Manager) execute(ctx context.Context, tool types.Tool, args map[string]string) (string, error) {
+	hooks := tm.getHooks(tool.Name)
+
+	var result string
+	var err error
+	if len(tool.Steps) > 0 {
+		result, err = tm.runSteps(ctx, tool, args)
+	} else {
+		if hooks.preRequest != nil {
+			tool.Request, args, err = hooks.preRequest.PreRequest(ctx, tool.Request, args)
+			if err != nil {
+				return "", err
+			}
+		}
+		result, err = tm.executor.Execute(ctx, tool.Request, args)
+	}
+	if err != nil || hooks.postResponse == nil {
+		return result, err
+	}
+
+	return hooks.postResponse.PostResponse(ctx, result)
+}
+
+func (tm *Manager) setHooks(name string, hooks toolHooks) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.hooks == nil {
+		tm.hooks = make(map[string]toolHooks)
+	}
+	tm.hooks[name] = hooks
+}
+
+func (tm *Manager) getHooks(name string) toolHooks {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	return tm.hooks[name]
+}
+
+func compileHooks(tool types.Tool) (toolHooks, error) {
+	var hooks toolHooks
+	var err error
+
+	if tool.PreRequest != nil {
+		if hooks.preRequest, err = compileHook(tool.Name+".preRequest", hook.PreRequestFunc, *tool.PreRequest); err != nil {
+			return hooks, err
+		}
+	}
+	if tool.PostResponse != nil {
+		if hooks.postResponse, err = compileHook(tool.Name+".postResponse", hook.PostResponseFunc, *tool.PostResponse); err != nil {
+			return hooks, err
+		}
+	}
+	return hooks, nil
+}
+
+func compileHook(name, function string, cfg types.Hook) (*hook.Hook, error) {
+	src := cfg.Script
+	if cfg.File != "" {
+		data, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hook file: %w", err)
+		}
+		src = string(data)
+	}
+	return hook.Compile(name, src, function, cfg)
+}
@@ -1,12 +1,19 @@
 package tool
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/netguard"
+	"github.com/AdamShannag/api-mcp-server/pkg/redact"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
 	"github.com/AdamShannag/api-mcp-server/pkg/resolver"
 	"github.com/AdamShannag/api-mcp-server/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/url"
 	"testing"
 )
 
@@ -119,12 +126,38 @@ func TestManager_ToolHandlerFactory_ExecuteError(t *testing.T) {
 
 	resp, err := handler(context.Background(), mcp.CallToolRequest{})
 
-	assert.Error(t, err)
+	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.True(t, resp.IsError)
 	assert.Contains(t, resp.Content[0].(mcp.TextContent).Text, "request failed")
 }
 
+func TestManager_ToolHandlerFactory_StatusError(t *testing.T) {
+	mockExec := &mockExecutor{
+		err: &request.StatusError{Response: types.ErrorResponse{
+			StatusCode: 404,
+			Status:     "404 Not Found",
+			Body:       map[string]any{"message": "not found"},
+			Hint:       "project not found, check project_id",
+		}},
+	}
+
+	mgr := NewManager(mockExec, WithArgResolver(&mockResolver{}))
+
+	handler := mgr.toolHandlerFactory(types.Tool{Name: "GetProject"})
+
+	resp, err := handler(context.Background(), mcp.CallToolRequest{})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.True(t, resp.IsError)
+
+	text := resp.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, `"status_code":404`)
+	assert.Contains(t, text, `"hint":"project not found, check project_id"`)
+	assert.Contains(t, text, `"message":"not found"`)
+}
+
 type mockResolver struct {
 	resolveFunc    func(ctx context.Context, req resolver.CallToolRequest, arg types.Arg) (string, error)
 	toToolOptionFn func(arg types.Arg) mcp.ToolOption
@@ -152,3 +185,111 @@ type mockExecutor struct {
 func (m *mockExecutor) Execute(_ context.Context, _ types.Request, _ map[string]string) (string, error) {
 	return m.output, m.err
 }
+
+func TestManager_AddTool_RegistersSensitiveArgs(t *testing.T) {
+	redactor := redact.New()
+	mgr := NewManager(&mockExecutor{}, WithRedactor(redactor))
+
+	err := mgr.AddTool(server.NewMCPServer("test", "1.0"), types.Tool{
+		Name: "Login",
+		Args: []types.Arg{
+			{Name: "user"},
+			{Name: "pin", Sensitive: true},
+		},
+		Batch: &types.Batch{},
+	})
+	assert.NoError(t, err)
+
+	login := redact.WithTool(context.Background(), "Login")
+	assert.True(t, redactor.KeyContext(login, "pin"))
+	assert.False(t, redactor.KeyContext(login, "user"))
+	assert.True(t, redactor.KeyContext(redact.WithTool(context.Background(), BatchName("Login")), "pin"))
+	assert.Equal(t, `{"pin": "[REDACTED]"}`, redactor.StringContext(login, `{"pin": "1234"}`))
+
+	// The names are only sensitive in the calls of the tool.
+	assert.False(t, redactor.Key("pin"))
+	assert.False(t, redactor.KeyContext(redact.WithTool(context.Background(), "Other"), "pin"))
+	assert.Equal(t, "pin=1234", redactor.String("pin=1234"))
+}
+
+func TestManager_ToolHandlerFactory_SensitiveValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(redact.New().Handler(slog.NewTextHandler(&buf, nil)))
+
+	exec := executorFunc(func(ctx context.Context, _ types.Request, args map[string]string) (string, error) {
+		logger.InfoContext(ctx, "executing", slog.String("url", "/users/"+args["user"]+"/unlock/"+args["pin"]))
+		return "ok", nil
+	})
+	handler := NewManager(exec).toolHandlerFactory(types.Tool{
+		Name: "Unlock",
+		Args: []types.Arg{{Name: "user", Type: "string"}, {Name: "pin", Type: "string", Sensitive: true}},
+	})
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = map[string]any{"user": "bob", "pin": "9876"}
+	_, err := handler(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "url=/users/bob/unlock/[REDACTED]")
+}
+
+func TestManager_ToolHandlerFactory_AllowedHosts(t *testing.T) {
+	exec := executorFunc(func(ctx context.Context, req types.Request, _ map[string]string) (string, error) {
+		return "ok", netguard.New().Check(ctx, &url.URL{Scheme: "https", Host: req.Host})
+	})
+	mgr := NewManager(exec)
+
+	handler := mgr.toolHandlerFactory(types.Tool{
+		Name:         "GetTodo",
+		Request:      types.Request{Host: "evil.example.com"},
+		AllowedHosts: []string{"api.example.com"},
+	})
+	resp, err := handler(context.Background(), mcp.CallToolRequest{})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.IsError)
+	assert.Contains(t, resp.Content[0].(mcp.TextContent).Text, netguard.ErrHostNotAllowed.Error())
+}
+
+func TestManager_AddTool_ReadOnly(t *testing.T) {
+	mgr := NewManager(&mockExecutor{}, WithReadOnly())
+	mcpServer := server.NewMCPServer("test", "1.0")
+
+	assert.NoError(t, mgr.AddTool(mcpServer, types.Tool{Name: "ListTodos", Request: types.Request{Method: "GET"}}))
+	assert.NoError(t, mgr.AddTool(mcpServer, types.Tool{Name: "DeleteTodo", Request: types.Request{Method: "DELETE"}}))
+	assert.NoError(t, mgr.AddTool(mcpServer, types.Tool{Name: "Workflow", Steps: []types.Step{
+		{Name: "get", Request: types.Request{Method: "GET"}},
+		{Name: "update", Request: types.Request{Method: "PATCH"}},
+	}}))
+
+	msg := mcpServer.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	tools := msg.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult).Tools
+	assert.Len(t, tools, 1)
+	assert.Equal(t, "ListTodos", tools[0].Name)
+}
+
+func TestManager_ToolHandlerFactory_OmittedArgs(t *testing.T) {
+	var omitted []string
+	exec := executorFunc(func(ctx context.Context, _ types.Request, args map[string]string) (string, error) {
+		omitted = request.OmittedArgs(ctx)
+		assert.Equal(t, "0", args["first"])
+		return "ok", nil
+	})
+	mgr := NewManager(exec)
+
+	handler := mgr.toolHandlerFactory(types.Tool{
+		Name: "ListIssues",
+		Args: []types.Arg{
+			{Name: "project", Type: "string", Required: true},
+			{Name: "first", Type: "int"},
+			{Name: "state", Type: "string", DefaultValue: "opened"},
+			{Name: "label", Type: "string"},
+		},
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"project": "gitlab", "label": "bug"}
+
+	_, err := handler(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first"}, omitted)
+}
@@ -0,0 +1,230 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AdamShannag/api-mcp-server/pkg/placeholder"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// workflow holds the state shared by the steps of a single tool call.
+type workflow struct {
+	args      map[string]string
+	extracted map[string]string
+	results   map[string]string
+}
+
+// runSteps executes the tool steps in order. Each step receives the tool args, overridden by its own
+// templated args, and may extract values from its response for later steps and the output template.
+// Without an output template, the result of the last executed step is returned. The pre-request hook of the
+// tool runs before each step, with the request and args of the step.
+func (tm *Manager) runSteps(ctx context.Context, tool types.Tool, args map[string]string) (string, error) {
+	preRequest := tm.getHooks(tool.Name).preRequest
+	wf := &workflow{
+		args:      args,
+		extracted: make(map[string]string),
+		results:   make(map[string]string),
+	}
+
+	var last string
+	for _, step := range tool.Steps {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		run, err := wf.shouldRun(step.When)
+		if err != nil {
+			return "", fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		if !run {
+			slog.Debug("workflow step skipped", slog.String("tool", tool.Name), slog.String("step", step.Name))
+			continue
+		}
+
+		stepArgs, err := wf.stepArgs(step)
+		if err != nil {
+			return "", fmt.Errorf("step %q: %w", step.Name, err)
+		}
+
+		// Args set by the step are passed, even when the caller omitted them.
+		stepCtx := ctx
+		if omitted := request.OmittedArgs(ctx); len(omitted) > 0 && len(step.Args) > 0 {
+			stepCtx = request.WithOmittedArgs(ctx, slices.DeleteFunc(omitted, func(name string) bool {
+				_, ok := step.Args[name]
+				return ok
+			})...)
+		}
+
+		stepRequest := step.Request
+		if preRequest != nil {
+			if stepRequest, stepArgs, err = preRequest.PreRequest(ctx, stepRequest, stepArgs); err != nil {
+				return "", fmt.Errorf("step %q: %w", step.Name, err)
+			}
+		}
+
+		result, err := tm.executor.Execute(stepCtx, stepRequest, stepArgs)
+		if err != nil {
+			return "", fmt.Errorf("step %q: %w", step.Name, err)
+		}
+
+		if err = wf.extract(step, result); err != nil {
+			return "", fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		wf.results[step.Name] = result
+		last = result
+	}
+
+	if tool.Output == "" {
+		return last, nil
+	}
+
+	output, err := wf.render(tool.Output)
+	if err != nil {
+		return "", fmt.Errorf("output: %w", err)
+	}
+	return output, nil
+}
+
+// render expands {{arg name}}, {{step name.value}} and {{result name}} placeholders.
+func (wf *workflow) render(tmpl string) (string, error) {
+	return placeholder.Expand(tmpl, map[string]placeholder.Func{
+		"arg": func(name string) (string, error) {
+			val, ok := wf.args[name]
+			if !ok {
+				return "", fmt.Errorf("unknown arg: %s", name)
+			}
+			return val, nil
+		},
+		"step": func(name string) (string, error) {
+			val, ok := wf.extracted[name]
+			if !ok {
+				return "", fmt.Errorf("unknown step value: %s", name)
+			}
+			return val, nil
+		},
+		"result": func(name string) (string, error) {
+			val, ok := wf.results[name]
+			if !ok {
+				return "", fmt.Errorf("unknown step result: %s", name)
+			}
+			return val, nil
+		},
+	})
+}
+
+// shouldRun renders the condition and reports whether it is truthy. A leading "!" negates it.
+// An empty condition always runs.
+func (wf *workflow) shouldRun(when string) (bool, error) {
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return true, nil
+	}
+
+	negate := strings.HasPrefix(when, "!")
+	val, err := wf.render(strings.TrimPrefix(when, "!"))
+	if err != nil {
+		return false, err
+	}
+
+	truthy := true
+	switch strings.TrimSpace(val) {
+	case "", "false", "0", "null":
+		truthy = false
+	}
+	return truthy != negate, nil
+}
+
+func (wf *workflow) stepArgs(step types.Step) (map[string]string, error) {
+	args := make(map[string]string, len(wf.args)+len(step.Args))
+	for k, v := range wf.args {
+		args[k] = v
+	}
+	for name, tmpl := range step.Args {
+		val, err := wf.render(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("arg %q: %w", name, err)
+		}
+		args[name] = val
+	}
+	return args, nil
+}
+
+// extract evaluates the step's extraction paths against its result, e.g. "body.id" or "status_code".
+// Extracted values are available to later steps as {{step <step>.<name>}}. Missing paths resolve to "".
+func (wf *workflow) extract(step types.Step, result string) error {
+	if len(step.Extract) == 0 {
+		return nil
+	}
+
+	var resp map[string]any
+	if err := decodeJSON(result, &resp); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	if body, ok := resp["body"].(string); ok {
+		var parsed any
+		if err := decodeJSON(body, &parsed); err == nil {
+			resp["body"] = parsed
+		}
+	}
+
+	for name, path := range step.Extract {
+		val, err := stringify(lookup(resp, path))
+		if err != nil {
+			return fmt.Errorf("extract %q: %w", name, err)
+		}
+		wf.extracted[step.Name+"."+name] = val
+	}
+	return nil
+}
+
+// decodeJSON decodes a single JSON value, keeping numbers as json.Number so large integer IDs keep their
+// precision.
+func decodeJSON(data string, v any) error {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+func lookup(val any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		switch v := val.(type) {
+		case map[string]any:
+			val = v[key]
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil
+			}
+			val = v[idx]
+		default:
+			return nil
+		}
+	}
+	return val
+}
+
+func stringify(val any) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
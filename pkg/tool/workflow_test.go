@@ -0,0 +1,187 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/AdamShannag/api-mcp-server/pkg/request"
+	"github.com/AdamShannag/api-mcp-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestManager_RunSteps(t *testing.T) {
+	var calls []string
+	exec := executorFunc(func(_ context.Context, req types.Request, args map[string]string) (string, error) {
+		calls = append(calls, req.Endpoint)
+		switch req.Endpoint {
+		case "/projects/:path":
+			assert.Equal(t, "group/app", args["path"])
+			return response(200, `{"id":42,"namespace":{"id":7}}`), nil
+		case "/projects/:id/issues":
+			assert.Equal(t, "42", args["id"])
+			assert.Equal(t, "Bug", args["title"])
+			return response(201, `{"iid":3,"web_url":"https://gitlab/issues/3"}`), nil
+		}
+		return "", errors.New("unexpected step")
+	})
+
+	mgr := NewManager(exec)
+	tool := types.Tool{
+		Name: "CreateIssueByPath",
+		Args: []types.Arg{{Name: "path"}, {Name: "title"}, {Name: "notify"}},
+		Steps: []types.Step{
+			{
+				Name:    "project",
+				Request: types.Request{Endpoint: "/projects/:path", PathParams: []string{"path"}},
+				Extract: map[string]string{"id": "body.id", "namespace": "body.namespace", "status": "status_code"},
+			},
+			{
+				Name:    "issue",
+				When:    "{{step project.id}}",
+				Request: types.Request{Endpoint: "/projects/:id/issues", PathParams: []string{"id"}},
+				Args:    map[string]string{"id": "{{step project.id}}"},
+				Extract: map[string]string{"url": "body.web_url"},
+			},
+			{
+				Name:    "notify",
+				When:    "{{arg notify}}",
+				Request: types.Request{Endpoint: "/notify"},
+			},
+		},
+		Output: `created {{step issue.url}} in project {{step project.id}} ({{step project.namespace}}, {{step project.status}})`,
+	}
+
+	out, err := mgr.runSteps(context.Background(), tool, map[string]string{"path": "group/app", "title": "Bug", "notify": "false"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/projects/:path", "/projects/:id/issues"}, calls)
+	assert.Equal(t, `created https://gitlab/issues/3 in project 42 ({"id":7}, 200)`, out)
+}
+
+func TestManager_RunSteps_PreRequestHook(t *testing.T) {
+	var signatures []string
+	exec := executorFunc(func(_ context.Context, req types.Request, args map[string]string) (string, error) {
+		signatures = append(signatures, req.Headers["X-Signature"]+" "+args["id"])
+		return response(200, `{"id":7}`), nil
+	})
+
+	mgr := NewManager(exec)
+	tool := types.Tool{
+		Name: "Signed",
+		PreRequest: &types.Hook{Script: `
+def pre_request(req):
+    req['headers']['X-Signature'] = 'sig-' + req['endpoint']
+    req['args']['id'] = req['args']['id'] + '!'
+`},
+		Steps: []types.Step{
+			{Name: "first", Request: types.Request{Endpoint: "/first"}, Extract: map[string]string{"id": "body.id"}},
+			{Name: "second", Request: types.Request{Endpoint: "/second"}, Args: map[string]string{"id": "{{step first.id}}"}},
+		},
+	}
+	hooks, err := compileHooks(tool)
+	assert.NoError(t, err)
+	mgr.setHooks(tool.Name, hooks)
+
+	_, err = mgr.execute(context.Background(), tool, map[string]string{"id": "1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sig-/first 1!", "sig-/second 7!"}, signatures)
+}
+
+func TestManager_RunSteps_DefaultOutputAndNegation(t *testing.T) {
+	exec := executorFunc(func(_ context.Context, req types.Request, _ map[string]string) (string, error) {
+		return response(200, req.Endpoint), nil
+	})
+
+	mgr := NewManager(exec)
+	tool := types.Tool{
+		Steps: []types.Step{
+			{Name: "first", Request: types.Request{Endpoint: "first"}, Extract: map[string]string{"missing": "body.nope"}},
+			{Name: "second", When: "!{{step first.missing}}", Request: types.Request{Endpoint: "second"}},
+			{Name: "third", When: "{{step first.missing}}", Request: types.Request{Endpoint: "third"}},
+		},
+	}
+
+	out, err := mgr.runSteps(context.Background(), tool, map[string]string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, response(200, "second"), out)
+}
+
+func TestManager_RunSteps_StepError(t *testing.T) {
+	exec := executorFunc(func(_ context.Context, _ types.Request, _ map[string]string) (string, error) {
+		return "", &request.StatusError{Response: types.ErrorResponse{StatusCode: 404, Status: "404 Not Found"}}
+	})
+
+	mgr := NewManager(exec, WithArgResolver(&mockResolver{}))
+	handler := mgr.toolHandlerFactory(types.Tool{
+		Name:  "Chained",
+		Steps: []types.Step{{Name: "lookup"}, {Name: "never"}},
+	})
+
+	resp, err := handler(context.Background(), mcp.CallToolRequest{})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.IsError)
+	assert.Contains(t, resp.Content[0].(mcp.TextContent).Text, `"status_code":404`)
+}
+
+func TestManager_RunSteps_Cancelled(t *testing.T) {
+	exec := executorFunc(func(_ context.Context, _ types.Request, _ map[string]string) (string, error) {
+		t.Fatal("step should not run")
+		return "", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewManager(exec).runSteps(ctx, types.Tool{Steps: []types.Step{{Name: "lookup"}}}, map[string]string{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestManager_RunSteps_UnknownReference(t *testing.T) {
+	exec := executorFunc(func(_ context.Context, _ types.Request, _ map[string]string) (string, error) {
+		return response(200, "{}"), nil
+	})
+
+	tool := types.Tool{Steps: []types.Step{{Name: "lookup", Args: map[string]string{"id": "{{step nope.id}}"}}}}
+
+	_, err := NewManager(exec).runSteps(context.Background(), tool, map[string]string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `step "lookup": arg "id"`)
+}
+
+func TestManager_RunSteps_LargeIntegers(t *testing.T) {
+	exec := executorFunc(func(_ context.Context, req types.Request, args map[string]string) (string, error) {
+		if req.Endpoint == "/items/:id" {
+			assert.Equal(t, "9007199254740993", args["id"])
+			return response(200, `{"ok":true}`), nil
+		}
+		return response(200, `{"id":9007199254740993,"price":1.5e3}`), nil
+	})
+
+	tool := types.Tool{
+		Steps: []types.Step{
+			{Name: "create", Request: types.Request{Endpoint: "/items"}, Extract: map[string]string{"id": "body.id", "price": "body.price"}},
+			{Name: "get", Request: types.Request{Endpoint: "/items/:id"}, Args: map[string]string{"id": "{{step create.id}}"}},
+		},
+		Output: "{{step create.id}} {{step create.price}}",
+	}
+
+	out, err := NewManager(exec).runSteps(context.Background(), tool, map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "9007199254740993 1.5e3", out)
+}
+
+type executorFunc func(context.Context, types.Request, map[string]string) (string, error)
+
+func (f executorFunc) Execute(ctx context.Context, req types.Request, args map[string]string) (string, error) {
+	return f(ctx, req, args)
+}
+
+func response(status int, body string) string {
+	data, _ := json.Marshal(types.Response{StatusCode: status, Body: body})
+	return string(data)
+}
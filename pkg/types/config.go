@@ -0,0 +1,58 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Config is the content of a tools config file. A bare array of tools is accepted as well.
+// Prefix is prepended to the names of the tools in the file.
+type Config struct {
+	Prefix  string                    `json:"prefix,omitempty"`
+	APIs    map[string]API            `json:"apis,omitempty"`
+	Plugins []Plugin                  `json:"plugins,omitempty"`
+	Secrets map[string]SecretProvider `json:"secrets,omitempty"`
+	Tools   []Tool                    `json:"tools"`
+}
+
+// API is a named upstream whose settings are shared by the requests referencing it.
+type API struct {
+	BaseURL string            `json:"baseURL"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Auth    *Auth             `json:"auth,omitempty"`
+	Timeout string            `json:"timeout,omitempty"`
+	Retry   *Retry            `json:"retry,omitempty"`
+	// RateLimit limits the calls to the host of the base URL, replacing the default host limit.
+	RateLimit string `json:"rateLimit,omitempty"`
+}
+
+// SecretProvider is a named source of secrets, referenced by {{secret <name> <key>}} placeholders.
+type SecretProvider struct {
+	Type    string            `json:"type"`
+	Path    string            `json:"path,omitempty"`
+	Key     string            `json:"key,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Field   string            `json:"field,omitempty"`
+	TTL     string            `json:"ttl,omitempty"`
+}
+
+type Plugin struct {
+	Name      string   `json:"name"`
+	Path      string   `json:"path"`
+	ArgTypes  []string `json:"argTypes,omitempty"`
+	Kinds     []string `json:"kinds,omitempty"`
+	Timeout   string   `json:"timeout,omitempty"`
+	MaxMemory int64    `json:"maxMemory,omitempty"`
+	AllowHTTP bool     `json:"allowHttp,omitempty"`
+}
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		*c = Config{}
+		return json.Unmarshal(data, &c.Tools)
+	}
+
+	type config Config
+	return json.Unmarshal(data, (*config)(c))
+}
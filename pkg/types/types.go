@@ -1,10 +1,41 @@
 package types
 
 type Tool struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Args        []Arg   `json:"args"`
-	Request     Request `json:"request"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Args         []Arg   `json:"args"`
+	Request      Request `json:"request"`
+	Steps        []Step  `json:"steps,omitempty"`
+	Output       string  `json:"output,omitempty"`
+	Batch        *Batch  `json:"batch,omitempty"`
+	PreRequest   *Hook   `json:"preRequest,omitempty"`
+	PostResponse *Hook   `json:"postResponse,omitempty"`
+	// AllowedHosts restricts the requests of the tool to matching hosts, in addition to the global allow-list.
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+	// RateLimit limits the calls of the tool, e.g. 10/s or 100/m, replacing the default tool limit.
+	RateLimit string `json:"rateLimit,omitempty"`
+}
+
+type Hook struct {
+	Script        string `json:"script,omitempty"`
+	File          string `json:"file,omitempty"`
+	MaxSteps      uint64 `json:"maxSteps,omitempty"`
+	MaxResultSize int64  `json:"maxResultSize,omitempty"`
+	MaxMemory     int64  `json:"maxMemory,omitempty"`
+	Timeout       string `json:"timeout,omitempty"`
+}
+
+type Batch struct {
+	Concurrency int `json:"concurrency,omitempty"`
+	MaxItems    int `json:"maxItems,omitempty"`
+}
+
+type Step struct {
+	Name    string            `json:"name"`
+	Request Request           `json:"request"`
+	Args    map[string]string `json:"args,omitempty"`
+	Extract map[string]string `json:"extract,omitempty"`
+	When    string            `json:"when,omitempty"`
 }
 
 type Arg struct {
@@ -13,20 +44,105 @@ type Arg struct {
 	Required     bool   `json:"required"`
 	DefaultValue any    `json:"defaultValue"`
 	Type         string `json:"type"`
+	// Sensitive masks the value of the arg in logs.
+	Sensitive bool `json:"sensitive,omitempty"`
 }
 
 type Request struct {
-	Host        string            `json:"host"`
-	Endpoint    string            `json:"endpoint"`
-	Method      string            `json:"method"`
-	Secure      bool              `json:"secure"`
-	Headers     map[string]string `json:"headers"`
-	PathParams  []string          `json:"pathParams"`
-	QueryParams []string          `json:"queryParams"`
-	Body        string            `json:"body,omitempty"`
+	Kind            string            `json:"kind,omitempty"`
+	API             string            `json:"api,omitempty"`
+	Host            string            `json:"host"`
+	Endpoint        string            `json:"endpoint"`
+	Method          string            `json:"method"`
+	Secure          bool              `json:"secure"`
+	Headers         map[string]string `json:"headers"`
+	PathParams      []string          `json:"pathParams"`
+	QueryParams     []string          `json:"queryParams"`
+	HeaderParams    []string          `json:"headerParams,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	BodyType        string            `json:"bodyType,omitempty"`
+	BodyParams      []string          `json:"bodyParams,omitempty"`
+	Files           []FilePart        `json:"files,omitempty"`
+	MaxResponseSize int64             `json:"maxResponseSize,omitempty"`
+	ResponseHeaders []string          `json:"responseHeaders,omitempty"`
+	StatusHints     map[string]string `json:"statusHints,omitempty"`
+	GraphQL         *GraphQLRequest   `json:"graphql,omitempty"`
+	GRPC            *GRPCRequest      `json:"grpc,omitempty"`
+	Auth            *Auth             `json:"auth,omitempty"`
+	Timeout         string            `json:"timeout,omitempty"`
+	Retry           *Retry            `json:"retry,omitempty"`
+	Cache           *Cache            `json:"cache,omitempty"`
+
+	// ArgTypes maps arg names to their types. It is filled in when the tool is registered.
+	ArgTypes map[string]string `json:"-"`
+}
+
+type Auth struct {
+	Type     string `json:"type"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Name     string `json:"name,omitempty"`
+	In       string `json:"in,omitempty"`
+}
+
+type Retry struct {
+	Attempts    int      `json:"attempts,omitempty"`
+	Backoff     string   `json:"backoff,omitempty"`
+	MaxBackoff  string   `json:"maxBackoff,omitempty"`
+	StatusCodes []int    `json:"statusCodes,omitempty"`
+	Methods     []string `json:"methods,omitempty"`
+}
+
+// Cache enables caching the responses of a GET or HEAD request.
+type Cache struct {
+	TTL string `json:"ttl,omitempty"`
+}
+
+type GraphQLRequest struct {
+	Query         string   `json:"query,omitempty"`
+	OperationName string   `json:"operationName,omitempty"`
+	Variables     []string `json:"variables,omitempty"`
+	Introspection string   `json:"introspection,omitempty"`
+}
+
+type GRPCRequest struct {
+	Method     string   `json:"method"`
+	Protoset   string   `json:"protoset,omitempty"`
+	Reflection bool     `json:"reflection,omitempty"`
+	Fields     []string `json:"fields,omitempty"`
+}
+
+type FilePart struct {
+	Field       string `json:"field"`
+	Arg         string `json:"arg"`
+	Source      string `json:"source"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
 }
 
 type Response struct {
-	StatusCode int    `json:"status_code"`
-	Body       string `json:"body"`
+	StatusCode   int               `json:"status_code"`
+	Body         string            `json:"body"`
+	Truncated    bool              `json:"truncated,omitempty"`
+	Continuation string            `json:"continuation,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+}
+
+// DryRun describes a request resolved in dry-run mode instead of being sent.
+type DryRun struct {
+	DryRun  bool              `json:"dry_run"`
+	Kind    string            `json:"kind,omitempty"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type ErrorResponse struct {
+	StatusCode int               `json:"status_code"`
+	Status     string            `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       any               `json:"body"`
+	Hint       string            `json:"hint,omitempty"`
 }